@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -13,16 +14,37 @@ import (
 // The last element of argv may be an empty string,
 // in which case all relevant completions will be written.
 //
+// Option values are completed from [Option.Choices] and [Option.Complete].
+// Positional arguments are completed from [Args.Choices] and
+// [Args.Completers], with their metavar as the default description,
+// until no more are allowed.
+// Options already supplied are omitted, unless they're [Option.Repeatable].
+// Short flags, like `-a` or `-ab`, are also extended with other short flags.
+//
 // Completions are line-separated.
 // Each line contains a potential completion, followed by '\t',
 // then a description of the completion.
 //
+// Lines starting with '\t' are instead directives for the shell,
+// for values marked with [Option.Path] or [Args.Paths]:
+//
+//   - `files`: complete file paths natively.
+//   - `files EXT...`: complete file paths with the given (space-separated)
+//     extensions, like `files json yaml`, as well as directories.
+//   - `dirs`: complete directory paths natively.
+//
+// In a `--long=value` arg, paths are completed after the '='.
+//
 // Regarding shell completion functions that use this function's output:
 //
 //   - The bash function should truncate each line at the '\t'.
 //   - The fish function doesn't need to truncate each line,
 //     as it will display the characters after the '\t' as a description
 //     for each completion.
+//   - The zsh function splits each line at the '\t',
+//     displaying the description alongside the completion in its menu.
+//   - The PowerShell, nushell and elvish completers also split each line at
+//     the '\t', displaying the description alongside the completion.
 func (app *App) Complete(w io.Writer, argv []string) {
 	if len(argv) <= 2 {
 		panic("argv appears truncated")
@@ -31,12 +53,9 @@ func (app *App) Complete(w io.Writer, argv []string) {
 	args := argv[2:]
 	i := len(args) - 1
 
-	// First ensure the arg being completed isn't beyond a --.
-	for j := 0; j < i; j++ {
-		if args[j] == "--" {
-			return
-		}
-	}
+	// If the arg being completed is beyond a --, it can only be a positional
+	// arg.
+	afterDash := slices.Contains(args[:i], "--")
 
 	cur := args[i]
 	prev := ""
@@ -51,6 +70,12 @@ func (app *App) Complete(w io.Writer, argv []string) {
 
 	singleCmd := len(app.Commands) == 1
 
+	directive := func(p *Path) {
+		if d := p.directive(); d != "" {
+			fmt.Fprintf(w, "\t%s\n", d)
+		}
+	}
+
 	completeFor := func(word, headline, headlineDefault string) {
 		if !strings.HasPrefix(word, cur) {
 			return
@@ -64,91 +89,111 @@ func (app *App) Complete(w io.Writer, argv []string) {
 		fmt.Fprintln(w)
 	}
 
-	var cmd *Command
+	// The first word is only a help word if it isn't being completed.
 	helpFirst := false
 
-	if app.hasHelpFlags() && (args[0] == "-h" || args[0] == "--help") {
-		helpFirst = true
+	if i > 0 {
+		if app.hasHelpFlags() && (args[0] == "-h" || args[0] == "--help") {
+			helpFirst = true
+		}
+		if app.hasHelpCommand() && args[0] == "help" {
+			helpFirst = true
+		}
 	}
-	if app.hasHelpCommand() && args[0] != "help" {
-		helpFirst = true
+
+	// Walk down the command tree through the args preceding the cursor.
+	path, cmds := app.rootPath()
+	rootDepth := len(path)
+	j := 0
+	if helpFirst {
+		j = 1
+	}
+	for ; j < i && len(cmds) != 0; j++ {
+		c := cmdMap(cmds)[args[j]]
+		if c == nil {
+			break
+		}
+		path = append(path, c)
+		cmds = c.Commands
 	}
+	nested := len(path) > rootDepth
 
 	// Can we complete a command (or -h/--help/help)?
-	if i == 0 || (helpFirst && i == 1) {
-		if !singleCmd {
-			for _, cmd := range app.Commands {
-				completeFor(cmd.Name, cmd.Headline, "Command")
-			}
+	singleOrDefault := singleCmd || app.DefaultCommand != ""
+	if j == i {
+		for _, cmd := range cmds {
+			completeFor(cmd.Name, cmd.Headline, "Command")
 		}
 
-		singleOrDefault := singleCmd || app.DefaultCommand != ""
-		if i == 0 {
+		if !helpFirst && (i == 0 || len(cmds) != 0) {
 			if app.hasHelpCommand() {
 				completeFor("help", "Show help", "")
 			}
-			if !singleOrDefault {
+			if !singleOrDefault || nested {
 				for _, f := range helpFlags {
 					completeFor(f, "Show help", "")
 				}
 			}
 		}
 
-		if !singleOrDefault {
+		if len(cmds) != 0 && (nested || !singleOrDefault) {
 			return
 		}
 	}
 
-	if singleCmd {
-		cmd = &app.Commands[0]
-	} else {
-		cmdArg := args[0]
-		if app.DefaultCommand != "" && (args[0] == "" || isOption(args[0])) {
-			cmdArg = app.DefaultCommand
+	if len(cmds) != 0 {
+		// We haven't reached a command that can be run yet. The default
+		// command is the only other possibility.
+		defaultable := !nested && app.DefaultCommand != "" &&
+			(args[0] == "" || isOption(args[0]))
+		if !defaultable {
+			return
 		}
 
-		for _, c := range app.Commands {
-			if c.Name == cmdArg {
-				cmd = &c
-				break
-			}
-		}
+		cmd := cmdMap(cmds)[app.DefaultCommand]
 		if cmd == nil {
 			return
 		}
+		path = append(path, cmd)
+	}
+
+	cmd := path[len(path)-1]
+
+	// Completion callbacks receive the result of parsing the args before n.
+	// Errors are expected, so ErrorHandler isn't called. This runs on every
+	// keypress, so validators and environment variables are skipped.
+	parsePartial := func(n int) *Result {
+		quiet := *app
+		quiet.ErrorHandler = nil
+		r := quiet.parse(append([]string{argv[0]}, args[:n]...), true)
+		r.App = app
+		return &r
 	}
 
+	// Options are resolved just as Parse resolves them, so that global
+	// options are included.
+	set := app.resolveOptions(path)
+	options := set.options
+
 	// Can we complete a non-flag (maybe with choices)?
-	if isOption(prev) && !strings.ContainsRune(prev, '=') {
-		var opt *Option
-		if isLongOption(prev) {
-			long := prev[2:]
-			for _, o := range cmd.Options {
-				if long == o.Long {
-					opt = &o
-					break
-				}
-			}
-		} else if len(prev) == 2 { // Ignore combined options
-			short := rune(prev[1])
-			for _, o := range cmd.Options {
-				if short == o.Short {
-					opt = &o
-					break
-				}
+	if !afterDash && !strings.ContainsRune(prev, '=') {
+		opt := set.lookup(prev)
+		if opt != nil {
+			metavar := "ARG"
+			if opt.Metavar != "" {
+				metavar = opt.Metavar
 			}
-		}
+			headline := fmt.Sprintf("%s %s", prev, metavar)
 
-		if opt != nil {
-			if len(opt.Choices) != 0 {
-				for _, c := range opt.Choices {
-					metavar := "ARG"
-					if opt.Metavar != "" {
-						metavar = opt.Metavar
-					}
-					completeFor(c, fmt.Sprintf("%s %s", prev, metavar), "")
+			for _, c := range opt.Choices {
+				completeFor(c, headline, "")
+			}
+			if opt.Complete != nil {
+				for _, c := range opt.Complete(parsePartial(i-1), cur) {
+					completeFor(c.Value, c.Description, headline)
 				}
 			}
+			directive(&opt.Path)
 
 			// If the option is expecting any value, don't complete further.
 			if !opt.Flag {
@@ -157,14 +202,121 @@ func (app *App) Complete(w io.Writer, argv []string) {
 		}
 	}
 
+	// Can we complete the value in a --long=value arg?
+	// The whole arg is completed, like `--opt=value`.
+	if !afterDash && isLongOption(cur) && strings.ContainsRune(cur, '=') {
+		eq := strings.IndexRune(cur, '=')
+		prefix := cur[:eq+1]
+
+		opt := set.lookup(cur)
+		if opt == nil || opt.Flag {
+			return
+		}
+
+		metavar := "ARG"
+		if opt.Metavar != "" {
+			metavar = opt.Metavar
+		}
+		headline := fmt.Sprintf("%s %s", cur[:eq], metavar)
+
+		for _, c := range opt.Choices {
+			completeFor(prefix+c, headline, "")
+		}
+		if opt.Complete != nil {
+			for _, c := range opt.Complete(parsePartial(i), cur[eq+1:]) {
+				completeFor(prefix+c.Value, c.Description, headline)
+			}
+		}
+		directive(&opt.Path)
+		return
+	}
+
+	// Parsing the preceding args tells us which options have already been
+	// supplied. Parse would reject them if supplied again, so they aren't
+	// completed unless they're repeatable.
+	prior := parsePartial(i)
+	used := func(opt *Option) bool {
+		if opt.Repeatable {
+			return false
+		}
+		o := prior.Options[optionKey(opt)]
+		return o != nil && o.IsSet && o.Source == SourceArgs
+	}
+
+	// Can we append to a combined short option, like `-ab`?
+	// Only flags can be combined. A single flag, like `-a`, is completed
+	// as-is too; any other single short option is completed as usual below.
+	if !afterDash && isOption(cur) && !isLongOption(cur) && len(cur) > 1 {
+		combined := []rune(cur[1:])
+		flags := true
+		for _, r := range combined {
+			if o := set.names[string(r)]; o == nil || !o.Flag {
+				flags = false
+				break
+			}
+		}
+
+		if flags {
+			if len(combined) == 1 {
+				opt := set.names[string(combined[0])]
+				if used(opt) {
+					return
+				}
+				completeFor(cur, opt.Headline, "Flag")
+			}
+
+			for _, opt := range options {
+				if opt.Short == 0 || !opt.Flag {
+					continue
+				}
+				if used(&opt) ||
+					(slices.Contains(combined, opt.Short) && !opt.Repeatable) {
+					continue
+				}
+				completeFor(cur+string(opt.Short), opt.Headline, "Flag")
+			}
+			return
+		}
+		if len(combined) > 1 {
+			return
+		}
+	}
+
+	// Can we complete a positional arg? The preceding args tell us how many
+	// have been supplied, skipping option values.
+	// Stop once no more are allowed.
+	if afterDash || !isOption(cur) {
+		n := len(prior.Args)
+		if n < cmd.Args.Count || cmd.Args.Varadic {
+			metavar := cmd.Args.metavar(n)
+			for _, c := range cmd.Args.choices(n) {
+				completeFor(c, "", metavar)
+			}
+			if complete := cmd.Args.completer(n); complete != nil {
+				for _, c := range complete(prior, cur) {
+					completeFor(c.Value, c.Description, metavar)
+				}
+			}
+			argPath := cmd.Args.path(n)
+			directive(&argPath)
+		}
+	}
+	if afterDash {
+		return
+	}
+
 	// Lastly, just complete options.
-	opts := append(app.GlobalOptions, cmd.Options...)
+	opts := options
 	if app.hasHelpFlags() {
 		helpOpt := fakeHelpOption
 		helpOpt.Headline = "Show help"
 		opts = append(opts, helpOpt)
 	}
 	for _, opt := range opts {
+		if used(&opt) {
+			continue
+		}
+
 		defaultHeadline := "Option"
 		if opt.Flag {
 			defaultHeadline = "Flag"
@@ -186,6 +338,28 @@ func quote(arg string) string {
 	return fmt.Sprintf("'%s'", strings.ReplaceAll(arg, "'", "\\'"))
 }
 
+// quotePOSIX quotes arg for POSIX-like shells (like zsh), in which
+// backslashes can't escape single quotes within single quotes.
+func quotePOSIX(arg string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(arg, "'", `'\''`))
+}
+
+// quotePowerShell quotes arg as a PowerShell verbatim string.
+func quotePowerShell(arg string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(arg, "'", "''"))
+}
+
+// quoteNu quotes arg as a nushell double-quoted string.
+func quoteNu(arg string) string {
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(arg, `"`, `\"`))
+}
+
+// quoteElvish quotes arg as an elvish single-quoted string.
+func quoteElvish(arg string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(arg, "'", "''"))
+}
+
 var idRe *regexp.Regexp
 
 // Derive a shell identifier-compatible name for program.
@@ -212,14 +386,56 @@ func GenerateBashCompletions(w io.Writer, program, flag string) {
 
 	// Write a function that calls the program with the required completion
 	// data.
+	// COMP_WORDS respects quoting, but it's also split at '=' and ':' (among
+	// other characters). Words that weren't separated by whitespace in the
+	// line are joined back together. bash will only replace the part of the
+	// current word after the last '=' or ':', so the rest is trimmed from
+	// each completion, and paths are completed from that part alone.
 	fmt.Fprintf(w, "%s() {\n", funcName)
-	fmt.Fprintln(w, "\tlocal cur=\"${COMP_WORDS[$COMP_CWORD]}\"")
-	fmt.Fprintln(w, "\tlocal iprev=\"$(( $COMP_CWORD - 1 ))\"")
+	fmt.Fprintln(w, "\tlocal line=\"${COMP_LINE:0:$COMP_POINT}\"")
+	fmt.Fprintln(w, "\tline=\"${line#\"${COMP_WORDS[0]}\"}\"")
+	fmt.Fprintln(w, "\tlocal words=() i word")
+	fmt.Fprintln(w, "\tfor (( i = 1; i <= COMP_CWORD; i++ )); do")
+	fmt.Fprintln(w, "\t\tword=\"${COMP_WORDS[i]}\"")
+	fmt.Fprintln(w, "\t\tif (( i > 1 )) && [[ \"$line\" != [[:space:]]* ]]; then")
+	fmt.Fprintln(w, "\t\t\twords[-1]+=\"$word\"")
+	fmt.Fprintln(w, "\t\telse")
+	fmt.Fprintln(w, "\t\t\twords+=(\"$word\")")
+	fmt.Fprintln(w, "\t\tfi")
+	fmt.Fprintln(w, "\t\tline=\"${line#\"${line%%[![:space:]]*}\"}\"")
+	fmt.Fprintln(w, "\t\tline=\"${line#\"$word\"}\"")
+	fmt.Fprintln(w, "\tdone")
+	fmt.Fprintln(w, "\tlocal cur=\"${words[-1]}\"")
+	fmt.Fprintln(w, "\tlocal part=\"${cur##*[=:]}\"")
+	fmt.Fprintln(w, "\tlocal trim=\"${cur%\"$part\"}\"")
 	fmt.Fprintln(w, "\twhile IFS= read -r c; do")
-	fmt.Fprintln(w, "\t\tCOMPREPLY+=(\"${c%%$'\\t'*}\")")
+	fmt.Fprintln(w, "\t\t[[ -z \"$c\" ]] && continue")
+
+	// Handle directives (see App.Complete) with compgen.
+	fmt.Fprintln(w, "\t\tif [[ \"$c\" == $'\\t'* ]]; then")
+	fmt.Fprintln(w, "\t\t\tlocal directive=(${c#$'\\t'})")
+	fmt.Fprintln(w, "\t\t\tcompopt -o filenames 2>/dev/null")
+	fmt.Fprintln(w, "\t\t\tcase \"${directive[0]}\" in")
+	fmt.Fprintln(w, "\t\t\tdirs)")
+	fmt.Fprintln(w, "\t\t\t\tmapfile -t -O \"${#COMPREPLY[@]}\" COMPREPLY < <(compgen -d -- \"$part\");;")
+	fmt.Fprintln(w, "\t\t\tfiles)")
+	fmt.Fprintln(w, "\t\t\t\tif (( ${#directive[@]} == 1 )); then")
+	fmt.Fprintln(w, "\t\t\t\t\tmapfile -t -O \"${#COMPREPLY[@]}\" COMPREPLY < <(compgen -f -- \"$part\")")
+	fmt.Fprintln(w, "\t\t\t\telse")
+	fmt.Fprintln(w, "\t\t\t\t\tmapfile -t -O \"${#COMPREPLY[@]}\" COMPREPLY < <(compgen -d -- \"$part\")")
+	fmt.Fprintln(w, "\t\t\t\t\tfor ext in \"${directive[@]:1}\"; do")
+	fmt.Fprintln(w, "\t\t\t\t\t\tmapfile -t -O \"${#COMPREPLY[@]}\" COMPREPLY < <(compgen -f -X \"!*.$ext\" -- \"$part\")")
+	fmt.Fprintln(w, "\t\t\t\t\tdone")
+	fmt.Fprintln(w, "\t\t\t\tfi;;")
+	fmt.Fprintln(w, "\t\t\tesac")
+	fmt.Fprintln(w, "\t\t\tcontinue")
+	fmt.Fprintln(w, "\t\tfi")
+
+	fmt.Fprintln(w, "\t\tc=\"${c%%$'\\t'*}\"")
+	fmt.Fprintln(w, "\t\tCOMPREPLY+=(\"${c#\"$trim\"}\")")
 	fmt.Fprintf(
 		w,
-		"\tdone <<< \"$(%s %s ${COMP_WORDS[@]:1:$iprev} \"$cur\")\"\n",
+		"\tdone <<< \"$(%s %s \"${words[@]}\")\"\n",
 		qprogram,
 		flag,
 	)
@@ -251,7 +467,265 @@ func GenerateFishCompletions(w io.Writer, program, flag string) {
 	fmt.Fprintf(w, "function %s\n", funcName)
 	fmt.Fprintln(w, "\tset -l tokens (commandline -cop)")
 	fmt.Fprintln(w, "\tset -l cur (commandline -ct)")
-	fmt.Fprintf(w, "\t%s %s $tokens[2..-1] \"$cur\"\n", qprogram, flag)
+	fmt.Fprintf(
+		w,
+		"\tfor line in (%s %s $tokens[2..-1] \"$cur\")\n",
+		qprogram,
+		flag,
+	)
+
+	// Handle directives (see App.Complete) with fish's own path completions.
+	fmt.Fprintln(w, "\t\tif string match -q \\t'*' -- $line")
+	fmt.Fprintln(w, "\t\t\tset -l directive (string split ' ' -- (string sub -s 2 -- $line))")
+	fmt.Fprintln(w, "\t\t\tswitch $directive[1]")
+	fmt.Fprintln(w, "\t\t\t\tcase dirs")
+	fmt.Fprintln(w, "\t\t\t\t\t__fish_complete_directories \"$cur\"")
+	fmt.Fprintln(w, "\t\t\t\tcase files")
+	fmt.Fprintln(w, "\t\t\t\t\tif set -q directive[2]")
+	fmt.Fprintln(w, "\t\t\t\t\t\tset -l pattern '(/|\\.('(string join '|' -- $directive[2..-1])'))$'")
+	fmt.Fprintln(w, "\t\t\t\t\t\tfor path in (__fish_complete_path \"$cur\")")
+	fmt.Fprintln(w, "\t\t\t\t\t\t\tif string match -q -r -- $pattern (string split -f1 \\t -- $path)")
+	fmt.Fprintln(w, "\t\t\t\t\t\t\t\techo $path")
+	fmt.Fprintln(w, "\t\t\t\t\t\t\tend")
+	fmt.Fprintln(w, "\t\t\t\t\t\tend")
+	fmt.Fprintln(w, "\t\t\t\t\telse")
+	fmt.Fprintln(w, "\t\t\t\t\t\t__fish_complete_path \"$cur\"")
+	fmt.Fprintln(w, "\t\t\t\t\tend")
+	fmt.Fprintln(w, "\t\t\tend")
+	fmt.Fprintln(w, "\t\telse")
+	fmt.Fprintln(w, "\t\t\techo $line")
+	fmt.Fprintln(w, "\t\tend")
+	fmt.Fprintln(w, "\tend")
 	fmt.Fprintln(w, "end")
 	fmt.Fprintf(w, "complete -c %s -f -k -a '(%s)'\n", qprogram, funcName)
 }
+
+// GenerateZshCompletions writes a zsh completion script to w.
+//
+// The script may be sourced (in which case it calls compdef itself),
+// or installed as a file named `_program` in a directory in the user's
+// fpath, to be autoloaded by compinit.
+//
+// program should be the program name (which will presumably be in the user's
+// PATH).
+// flag should be a special trigger flag, *including* hyphen prefixes,
+// which your program should use to bypass normal execution
+// and generate completions instead (using [App.Complete]).
+//
+// flag can be anything you want, but don't use anything ambiguous to your CLI.
+// If in doubt, use "--_complete".
+func GenerateZshCompletions(w io.Writer, program, flag string) {
+	qprogram := quotePOSIX(program)
+	funcName := fmt.Sprintf("_complete_charli_%s", shellID(program))
+
+	fmt.Fprintf(w, "#compdef %s\n", program)
+
+	// The words on the command line are as the user typed them, so their
+	// quoting is removed with (Q) before they're passed to the program.
+	// Colons in completions are escaped for _describe, which quotes any other
+	// special characters itself.
+	fmt.Fprintf(w, "%s() {\n", funcName)
+	fmt.Fprintln(w, "\tlocal -a completions directive")
+	fmt.Fprintln(w, "\tlocal line word desc")
+	fmt.Fprintln(w, "\twhile IFS= read -r line; do")
+	fmt.Fprintln(w, "\t\t[[ -z $line ]] && continue")
+	fmt.Fprintln(w, "\t\tif [[ $line == $'\\t'* ]]; then")
+	fmt.Fprintln(w, "\t\t\tdirective=(${=line#$'\\t'})")
+	fmt.Fprintln(w, "\t\t\tcontinue")
+	fmt.Fprintln(w, "\t\tfi")
+	fmt.Fprintln(w, "\t\tword=${line%%$'\\t'*}")
+	fmt.Fprintln(w, "\t\tdesc=${line#*$'\\t'}")
+	fmt.Fprintln(w, "\t\tword=${word//:/\\\\:}")
+	fmt.Fprintln(w, "\t\tif [[ $line == *$'\\t'* && -n $desc ]]; then")
+	fmt.Fprintln(w, "\t\t\tcompletions+=(\"$word:$desc\")")
+	fmt.Fprintln(w, "\t\telse")
+	fmt.Fprintln(w, "\t\t\tcompletions+=(\"$word\")")
+	fmt.Fprintln(w, "\t\tfi")
+	fmt.Fprintf(
+		w,
+		"\tdone < <(%s %s \"${(@Q)words[2,CURRENT-1]}\" \"${(Q)PREFIX}\")\n",
+		qprogram,
+		flag,
+	)
+	fmt.Fprintln(w, "\t_describe -V completions completions")
+
+	// Handle directives (see App.Complete) with _files.
+	fmt.Fprintln(w, "\tcase $directive[1] in")
+	fmt.Fprintln(w, "\tdirs) _files -/ ;;")
+	fmt.Fprintln(w, "\tfiles)")
+	fmt.Fprintln(w, "\t\tif (( $#directive > 1 )); then")
+	fmt.Fprintln(w, "\t\t\t_files -g \"*.(${(j:|:)directive[2,-1]})\"")
+	fmt.Fprintln(w, "\t\telse")
+	fmt.Fprintln(w, "\t\t\t_files")
+	fmt.Fprintln(w, "\t\tfi ;;")
+	fmt.Fprintln(w, "\tesac")
+	fmt.Fprintln(w, "}")
+
+	// Complete using the function, or call it if this file is being autoloaded
+	// as the completion function itself.
+	fmt.Fprintln(w, "if [[ $zsh_eval_context[-1] == loadautofunc ]]; then")
+	fmt.Fprintf(w, "\t%s \"$@\"\n", funcName)
+	fmt.Fprintln(w, "else")
+	fmt.Fprintf(w, "\tcompdef %s %s\n", funcName, qprogram)
+	fmt.Fprintln(w, "fi")
+}
+
+// GeneratePowerShellCompletions writes a PowerShell completion script to w.
+// The script should be dot-sourced, usually from the user's $PROFILE.
+//
+// program should be the program name (which will presumably be in the user's
+// PATH).
+// flag should be a special trigger flag, *including* hyphen prefixes,
+// which your program should use to bypass normal execution
+// and generate completions instead (using [App.Complete]).
+//
+// flag can be anything you want, but don't use anything ambiguous to your CLI.
+// If in doubt, use "--_complete".
+func GeneratePowerShellCompletions(w io.Writer, program, flag string) {
+	qprogram := quotePowerShell(program)
+
+	fmt.Fprintf(
+		w,
+		"Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n",
+		qprogram,
+	)
+	fmt.Fprintln(w, "\tparam($wordToComplete, $commandAst, $cursorPosition)")
+
+	// Pass the words before the cursor (without the program name) to the
+	// program, unquoting any string literals.
+	fmt.Fprintln(w, "\t$words = @($commandAst.CommandElements |")
+	fmt.Fprintln(w, "\t\tWhere-Object { $_.Extent.EndOffset -lt $cursorPosition } |")
+	fmt.Fprintln(w, "\t\tSelect-Object -Skip 1 |")
+	fmt.Fprintln(w, "\t\tForEach-Object {")
+	fmt.Fprintln(w, "\t\t\tif ($_ -is [System.Management.Automation.Language.StringConstantExpressionAst]) {")
+	fmt.Fprintln(w, "\t\t\t\t$_.Value")
+	fmt.Fprintln(w, "\t\t\t} else {")
+	fmt.Fprintln(w, "\t\t\t\t$_.ToString()")
+	fmt.Fprintln(w, "\t\t\t}")
+	fmt.Fprintln(w, "\t\t})")
+	fmt.Fprintln(w, "\t$words += $wordToComplete")
+
+	// Windows PowerShell and pwsh < 7.3 drop empty arguments to native
+	// commands, so an empty word to complete would be lost (shifting the
+	// position being completed). Empty words are passed as "" instead.
+	fmt.Fprintln(w, "\t$passing = Get-Variable PSNativeCommandArgumentPassing -ValueOnly -ErrorAction Ignore")
+	fmt.Fprintln(w, "\tif (-not $passing -or $passing -eq 'Legacy') {")
+	fmt.Fprintln(w, "\t\t$words = @($words | ForEach-Object { if ($_ -eq '') { '\"\"' } else { $_ } })")
+	fmt.Fprintln(w, "\t}")
+
+	// Turn each line into a CompletionResult. Words containing special
+	// characters are quoted, and the tooltip can't be empty.
+	// Directives (see App.Complete) are skipped: if there are no other
+	// completions, PowerShell falls back to completing paths itself.
+	fmt.Fprintf(
+		w,
+		"\t& %s %s @words | ForEach-Object {\n",
+		qprogram,
+		flag,
+	)
+	fmt.Fprintln(w, "\t\t$word, $desc = $_ -split \"`t\", 2")
+	fmt.Fprintln(w, "\t\tif (-not $word) { return }")
+	fmt.Fprintln(w, "\t\tif (-not $desc) { $desc = $word }")
+	fmt.Fprintln(w, "\t\t$text = $word")
+	fmt.Fprintln(w, "\t\tif ($word -match '[\\s''\"`$&(){};,|@#<>]') {")
+	fmt.Fprintln(w, "\t\t\t$text = \"'\" + ($word -replace \"'\", \"''\") + \"'\"")
+	fmt.Fprintln(w, "\t\t}")
+	fmt.Fprintln(w, "\t\t$type = 'ParameterValue'")
+	fmt.Fprintln(w, "\t\tif ($word.StartsWith('-')) { $type = 'ParameterName' }")
+	fmt.Fprintln(w, "\t\t[System.Management.Automation.CompletionResult]::new(")
+	fmt.Fprintln(w, "\t\t\t$text, $word, $type, $desc")
+	fmt.Fprintln(w, "\t\t)")
+	fmt.Fprintln(w, "\t}")
+	fmt.Fprintln(w, "}")
+}
+
+// GenerateNushellCompletions writes a nushell completion script to w.
+// The script defines an extern for the program, with a custom completer,
+// and should be sourced (or used as a module) from the user's config.
+//
+// program should be the program name (which will presumably be in the user's
+// PATH).
+// flag should be a special trigger flag, *including* hyphen prefixes,
+// which your program should use to bypass normal execution
+// and generate completions instead (using [App.Complete]).
+//
+// flag can be anything you want, but don't use anything ambiguous to your CLI.
+// If in doubt, use "--_complete".
+func GenerateNushellCompletions(w io.Writer, program, flag string) {
+	qprogram := quoteNu(program)
+	funcName := quoteNu(fmt.Sprintf("nu-complete charli %s", shellID(program)))
+
+	// The completer receives the command line up to the cursor. If it ends
+	// with a space, splitting it leaves an empty word to complete.
+	fmt.Fprintf(w, "def %s [context: string] {\n", funcName)
+	fmt.Fprintln(w, "\tlet args = ($context | split row -r '\\s+' | skip 1)")
+	fmt.Fprintf(
+		w,
+		"\tlet lines = (^%s %s ...$args | lines | where $it != \"\")\n",
+		qprogram,
+		flag,
+	)
+
+	// Directives (see App.Complete) are handled by returning null, which makes
+	// nushell fall back to completing paths itself.
+	fmt.Fprintln(w, "\tif ($lines | any {|line| $line | str starts-with \"\\t\" }) {")
+	fmt.Fprintln(w, "\t\treturn null")
+	fmt.Fprintln(w, "\t}")
+	fmt.Fprintln(w, "\t$lines | each {|line|")
+	fmt.Fprintln(w, "\t\tlet parts = ($line | split row -n 2 \"\\t\")")
+	fmt.Fprintln(w, "\t\tlet desc = if ($parts | length) > 1 { $parts.1 } else { \"\" }")
+	fmt.Fprintln(w, "\t\t{value: $parts.0, description: $desc}")
+	fmt.Fprintln(w, "\t}")
+	fmt.Fprintln(w, "}")
+
+	fmt.Fprintf(w, "extern %s [\n", qprogram)
+	fmt.Fprintf(w, "\t...args: string@%s\n", funcName)
+	fmt.Fprintln(w, "]")
+}
+
+// GenerateElvishCompletions writes an elvish completion script to w.
+// The script sets an entry in edit:completion:arg-completer,
+// and should be evaluated from the user's rc.elv.
+//
+// program should be the program name (which will presumably be in the user's
+// PATH).
+// flag should be a special trigger flag, *including* hyphen prefixes,
+// which your program should use to bypass normal execution
+// and generate completions instead (using [App.Complete]).
+//
+// flag can be anything you want, but don't use anything ambiguous to your CLI.
+// If in doubt, use "--_complete".
+func GenerateElvishCompletions(w io.Writer, program, flag string) {
+	qprogram := quoteElvish(program)
+
+	// The completer receives every word, including the program name and the
+	// word being completed (which may be empty).
+	fmt.Fprintln(w, "use str")
+	fmt.Fprintf(
+		w,
+		"set edit:completion:arg-completer[%s] = {|@words|\n",
+		qprogram,
+	)
+	fmt.Fprintln(w, "\tvar args = $words[1..]")
+	fmt.Fprintf(
+		w,
+		"\t(external %s) %s $@args | from-lines | each {|line|\n",
+		qprogram,
+		flag,
+	)
+	fmt.Fprintln(w, "\t\tif (==s $line '') { continue }")
+
+	// Handle directives (see App.Complete) with elvish's own path completions.
+	fmt.Fprintln(w, "\t\tif (str:has-prefix $line \"\\t\") {")
+	fmt.Fprintln(w, "\t\t\tedit:complete-filename $words[-1]")
+	fmt.Fprintln(w, "\t\t\tcontinue")
+	fmt.Fprintln(w, "\t\t}")
+	fmt.Fprintln(w, "\t\tvar parts = [(str:split &max=2 \"\\t\" $line)]")
+	fmt.Fprintln(w, "\t\tif (and (> (count $parts) 1) (!=s $parts[1] '')) {")
+	fmt.Fprintln(w, "\t\t\tedit:complex-candidate $parts[0] &display=$parts[0]' ('$parts[1]')'")
+	fmt.Fprintln(w, "\t\t} else {")
+	fmt.Fprintln(w, "\t\t\tedit:complex-candidate $parts[0]")
+	fmt.Fprintln(w, "\t\t}")
+	fmt.Fprintln(w, "\t}")
+	fmt.Fprintln(w, "}")
+}
@@ -3,6 +3,7 @@ package charli_test
 import (
 	"bytes"
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 
@@ -44,6 +45,171 @@ var app = charli.App{
 	},
 }
 
+var appNested = charli.App{
+	Commands: []charli.Command{
+		{
+			Name:     "remote",
+			Headline: "Manage remotes",
+			Options: []charli.Option{
+				{
+					Long: "dry-run",
+					Flag: true,
+				},
+			},
+			Commands: []charli.Command{
+				{
+					Name:     "add",
+					Headline: "Add a remote",
+					Options: []charli.Option{
+						{
+							Short: 'f',
+							Flag:  true,
+						},
+					},
+				},
+				{
+					Name: "prune",
+				},
+			},
+		},
+		{
+			Name: "cmd2",
+		},
+	},
+}
+
+// Completes branches for the remote chosen earlier on the command line.
+func completeBranch(r *charli.Result, cur string) []charli.Completion {
+	remote := r.Options["remote"].Value
+	return []charli.Completion{
+		{Value: remote + "/main", Description: "Default branch"},
+		{Value: remote + "/dev"},
+	}
+}
+
+// Completes services, excluding any already on the command line.
+func completeService(r *charli.Result, cur string) []charli.Completion {
+	var completions []charli.Completion
+	for _, s := range []string{"api", "web", "worker"} {
+		if !slices.Contains(r.Args, s) {
+			completions = append(completions, charli.Completion{Value: s})
+		}
+	}
+	return completions
+}
+
+var appDynamic = charli.App{
+	Commands: []charli.Command{
+		{
+			Name: "deploy",
+			Options: []charli.Option{
+				{Long: "remote", Choices: []string{"origin", "fork"}},
+				{Short: 'b', Long: "branch", Complete: completeBranch},
+			},
+			Args: charli.Args{
+				Count:    1,
+				Varadic:  true,
+				Metavars: []string{"ENV", "SERVICE"},
+				Completers: []func(*charli.Result, string) []charli.Completion{
+					nil,
+					completeService,
+				},
+			},
+		},
+	},
+}
+
+var appPaths = charli.App{
+	Commands: []charli.Command{
+		{
+			Options: []charli.Option{
+				{
+					Long: "config",
+					Path: charli.Path{
+						Kind:       charli.FilePath,
+						Extensions: []string{"json", ".yaml"},
+					},
+				},
+				{Long: "out", Choices: []string{"-"}, Path: charli.Path{
+					Kind: charli.FilePath,
+				}},
+			},
+			Args: charli.Args{
+				Count: 2,
+				Paths: []charli.Path{{Kind: charli.DirPath}},
+			},
+		},
+	},
+	HelpAccess: charli.HelpCommand,
+}
+
+var appArgs = charli.App{
+	Commands: []charli.Command{
+		{
+			Name: "set",
+			Options: []charli.Option{
+				{Short: 'v', Flag: true},
+				{Long: "scope"},
+			},
+			Args: charli.Args{
+				Count:    2,
+				Metavars: []string{"KEY", "VALUE"},
+				Choices:  [][]string{{"color", "editor"}},
+			},
+		},
+		{
+			Name: "rm",
+			Args: charli.Args{
+				Varadic:  true,
+				Metavars: []string{"KEY"},
+				Choices:  [][]string{{"color", "editor"}},
+			},
+		},
+	},
+}
+
+var appCombined = charli.App{
+	Commands: []charli.Command{
+		{
+			Options: []charli.Option{
+				{Short: 'a', Flag: true},
+				{Short: 'b', Flag: true},
+				{
+					Short:      'v',
+					Flag:       true,
+					Repeatable: true,
+					Headline:   "Verbose",
+				},
+				{Short: 'o', Long: "output"},
+			},
+		},
+	},
+	GlobalOptions: []charli.Option{
+		{Short: 'q', Flag: true},
+	},
+}
+
+var appGlobal = charli.App{
+	Commands: []charli.Command{
+		{
+			Args: charli.Args{
+				Count:    1,
+				Metavars: []string{"MODE"},
+				Choices:  [][]string{{"fast", "slow"}},
+			},
+		},
+	},
+	GlobalOptions: []charli.Option{
+		{
+			Short:   'c',
+			Long:    "color",
+			Choices: []string{"auto", "never"},
+		},
+		{Long: "config"},
+		{Short: 'q', Flag: true},
+	},
+}
+
 var appWithDefault = app
 var appSingleCmd = app
 var appHelpCmd = app
@@ -165,6 +331,16 @@ func TestComplete(t *testing.T) {
 			argv: []string{"program", "_c", "-h", "cmd1"},
 			want: []string{"cmd1\tHeadline1"},
 		},
+		{
+			app:  app,
+			argv: []string{"program", "_c", "--h"},
+			want: []string{"--help\tShow help"},
+		},
+		{
+			app:  app,
+			argv: []string{"program", "_c", "-h"},
+			want: []string{"-h\tShow help"},
+		},
 		{
 			app:  app,
 			argv: []string{"program", "_c", "cmd"},
@@ -233,6 +409,11 @@ func TestComplete(t *testing.T) {
 				"help\tShow help",
 			},
 		},
+		{
+			app:  appHelpCmd,
+			argv: []string{"program", "_c", "help"},
+			want: []string{"help\tShow help"},
+		},
 		{
 			app:  appSingleCmdWithHelp,
 			argv: []string{"program", "_c", ""},
@@ -255,6 +436,322 @@ func TestComplete(t *testing.T) {
 				"--help\tShow help",
 			},
 		},
+		{
+			app:  appNested,
+			argv: []string{"program", "_c", ""},
+			want: []string{
+				"remote\tManage remotes",
+				"cmd2\tCommand",
+				"-h\tShow help",
+				"--help\tShow help",
+			},
+		},
+		{
+			app:  appNested,
+			argv: []string{"program", "_c", "remote", ""},
+			want: []string{
+				"add\tAdd a remote",
+				"prune\tCommand",
+				"-h\tShow help",
+				"--help\tShow help",
+			},
+		},
+		{
+			app:  appNested,
+			argv: []string{"program", "_c", "-h", "remote", "p"},
+			want: []string{"prune\tCommand"},
+		},
+		{
+			app:  appNested,
+			argv: []string{"program", "_c", "remote", "add", ""},
+			want: []string{
+				"--dry-run\tFlag",
+				"-f\tFlag",
+				"-h\tShow help",
+				"--help\tShow help",
+			},
+		},
+		{
+			app:  appNested,
+			argv: []string{"program", "_c", "remote", "nope", ""},
+			want: []string{},
+		},
+		{
+			app:  appDynamic,
+			argv: []string{"program", "_c", "--remote", "fork", "--branch", ""},
+			want: []string{
+				"fork/main\tDefault branch",
+				"fork/dev\t--branch ARG",
+			},
+		},
+		{
+			app:  appDynamic,
+			argv: []string{"program", "_c", "--remote=origin", "-b", "origin/m"},
+			want: []string{"origin/main\tDefault branch"},
+		},
+		{
+			app:  appDynamic,
+			argv: []string{"program", "_c", ""},
+			want: []string{
+				"--remote\tOption",
+				"-b\tOption",
+				"--branch\tOption",
+				"-h\tShow help",
+				"--help\tShow help",
+			},
+		},
+		{
+			app:  appDynamic,
+			argv: []string{"program", "_c", "prod", "web", "-b", "x", ""},
+			want: []string{
+				"api\tSERVICE",
+				"worker\tSERVICE",
+				"--remote\tOption",
+				"-h\tShow help",
+				"--help\tShow help",
+			},
+		},
+		{
+			app:  appDynamic,
+			argv: []string{"program", "_c", "prod", "-"},
+			want: []string{
+				"--remote\tOption",
+				"-b\tOption",
+				"--branch\tOption",
+				"-h\tShow help",
+				"--help\tShow help",
+			},
+		},
+		{
+			app:  appPaths,
+			argv: []string{"program", "_c", "--config", ""},
+			want: []string{"\tfiles json yaml"},
+		},
+		{
+			app:  appPaths,
+			argv: []string{"program", "_c", "--out", ""},
+			want: []string{"-\t--out ARG", "\tfiles"},
+		},
+		{
+			app:  appPaths,
+			argv: []string{"program", "_c", "--config="},
+			want: []string{"\tfiles json yaml"},
+		},
+		{
+			app:  appPaths,
+			argv: []string{"program", "_c", "--out="},
+			want: []string{"--out=-\t--out ARG", "\tfiles"},
+		},
+		{
+			app:  appPaths,
+			argv: []string{"program", "_c", ""},
+			want: []string{
+				"help\tShow help",
+				"\tdirs",
+				"--config\tOption",
+				"--out\tOption",
+			},
+		},
+		{
+			app:  appPaths,
+			argv: []string{"program", "_c", "a", ""},
+			want: []string{
+				"--config\tOption",
+				"--out\tOption",
+			},
+		},
+		{
+			app:  appArgs,
+			argv: []string{"program", "_c", "set", ""},
+			want: []string{
+				"color\tKEY",
+				"editor\tKEY",
+				"-v\tFlag",
+				"--scope\tOption",
+				"-h\tShow help",
+				"--help\tShow help",
+			},
+		},
+		{
+			app:  appArgs,
+			argv: []string{"program", "_c", "set", "--scope", "user", "-v", "e"},
+			want: []string{"editor\tKEY"},
+		},
+		{
+			// The second arg has no choices.
+			app:  appArgs,
+			argv: []string{"program", "_c", "set", "color", "--scope", "x", ""},
+			want: []string{
+				"-v\tFlag",
+				"-h\tShow help",
+				"--help\tShow help",
+			},
+		},
+		{
+			// No more args are allowed.
+			app:  appArgs,
+			argv: []string{"program", "_c", "set", "--", "color", "red", ""},
+			want: []string{},
+		},
+		{
+			app:  appArgs,
+			argv: []string{"program", "_c", "set", "--", "c"},
+			want: []string{"color\tKEY"},
+		},
+		{
+			app:  appArgs,
+			argv: []string{"program", "_c", "rm", "color", "--", "editor", ""},
+			want: []string{"color\tKEY", "editor\tKEY"},
+		},
+		{
+			app:  app,
+			argv: []string{"program", "_c", "cmd1", "--choice="},
+			want: []string{
+				"--choice=aa\t--choice C",
+				"--choice=bb\t--choice C",
+			},
+		},
+		{
+			app:  app,
+			argv: []string{"program", "_c", "cmd1", "--choice=b"},
+			want: []string{"--choice=bb\t--choice C"},
+		},
+		{
+			app:  app,
+			argv: []string{"program", "_c", "cmd1", "--value="},
+			want: []string{},
+		},
+		{
+			app:  appDynamic,
+			argv: []string{"program", "_c", "--remote=fork", "--branch=fork/d"},
+			want: []string{"--branch=fork/dev\t--branch ARG"},
+		},
+		{
+			app:  appCombined,
+			argv: []string{"program", "_c", "-ab"},
+			want: []string{
+				"-abq\tFlag",
+				"-abv\tVerbose",
+			},
+		},
+		{
+			app:  appCombined,
+			argv: []string{"program", "_c", "-v"},
+			want: []string{
+				"-v\tVerbose",
+				"-vq\tFlag",
+				"-va\tFlag",
+				"-vb\tFlag",
+				"-vv\tVerbose",
+			},
+		},
+		{
+			// -a can't be repeated.
+			app:  appCombined,
+			argv: []string{"program", "_c", "-a"},
+			want: []string{
+				"-a\tFlag",
+				"-aq\tFlag",
+				"-ab\tFlag",
+				"-av\tVerbose",
+			},
+		},
+		{
+			app:  appCombined,
+			argv: []string{"program", "_c", "-a", "-a"},
+			want: []string{},
+		},
+		{
+			// -o takes a value, so it's completed as usual.
+			app:  appCombined,
+			argv: []string{"program", "_c", "-o"},
+			want: []string{"-o\tOption"},
+		},
+		{
+			app:  appCombined,
+			argv: []string{"program", "_c", "-vv"},
+			want: []string{
+				"-vvq\tFlag",
+				"-vva\tFlag",
+				"-vvb\tFlag",
+				"-vvv\tVerbose",
+			},
+		},
+		{
+			// -a was supplied earlier, and -b is already in this cluster.
+			app:  appCombined,
+			argv: []string{"program", "_c", "-a", "-vb"},
+			want: []string{
+				"-vbq\tFlag",
+				"-vbv\tVerbose",
+			},
+		},
+		{
+			// Only the repeatable -v is offered again.
+			app:  appCombined,
+			argv: []string{"program", "_c", "-qv", "--output", "x", "-"},
+			want: []string{
+				"-a\tFlag",
+				"-b\tFlag",
+				"-v\tVerbose",
+				"-h\tShow help",
+				"--help\tShow help",
+			},
+		},
+		{
+			// -o takes a value, so can't be combined.
+			app:  appCombined,
+			argv: []string{"program", "_c", "-ao"},
+			want: []string{},
+		},
+		{
+			app:  appGlobal,
+			argv: []string{"program", "_c", "--color", ""},
+			want: []string{"auto\t--color ARG", "never\t--color ARG"},
+		},
+		{
+			app:  appGlobal,
+			argv: []string{"program", "_c", "-c", "n"},
+			want: []string{"never\t-c ARG"},
+		},
+		{
+			// Like Parse, accept a short option with 2 hyphens.
+			app:  appGlobal,
+			argv: []string{"program", "_c", "--c", ""},
+			want: []string{"auto\t--c ARG", "never\t--c ARG"},
+		},
+		{
+			// --config is expecting a value, so nothing else is completed.
+			app:  appGlobal,
+			argv: []string{"program", "_c", "--config", ""},
+			want: []string{},
+		},
+		{
+			app:  appGlobal,
+			argv: []string{"program", "_c", "-q", ""},
+			want: []string{
+				"fast\tMODE",
+				"slow\tMODE",
+				"-c\tOption",
+				"--color\tOption",
+				"--config\tOption",
+				"-h\tShow help",
+				"--help\tShow help",
+			},
+		},
+		{
+			app:  appGlobal,
+			argv: []string{"program", "_c", "--config", "x", ""},
+			want: []string{
+				"fast\tMODE",
+				"slow\tMODE",
+				"-c\tOption",
+				"--color\tOption",
+				"-q\tFlag",
+				"-h\tShow help",
+				"--help\tShow help",
+			},
+		},
 		{
 			app:       app,
 			argv:      []string{"program"},
@@ -282,7 +779,7 @@ func TestComplete(t *testing.T) {
 
 			var buf bytes.Buffer
 			test.app.Complete(&buf, test.argv)
-			got := strings.TrimSpace(buf.String())
+			got := strings.TrimRight(buf.String(), "\n")
 			want := strings.Join(test.want, "\n")
 
 			dmp := diffmatchpatch.New()
@@ -300,11 +797,45 @@ func TestComplete(t *testing.T) {
 
 var wantBash = `
 _complete_charli_a_a_-A0_() {
-	local cur="${COMP_WORDS[$COMP_CWORD]}"
-	local iprev="$(( $COMP_CWORD - 1 ))"
+	local line="${COMP_LINE:0:$COMP_POINT}"
+	line="${line#"${COMP_WORDS[0]}"}"
+	local words=() i word
+	for (( i = 1; i <= COMP_CWORD; i++ )); do
+		word="${COMP_WORDS[i]}"
+		if (( i > 1 )) && [[ "$line" != [[:space:]]* ]]; then
+			words[-1]+="$word"
+		else
+			words+=("$word")
+		fi
+		line="${line#"${line%%[![:space:]]*}"}"
+		line="${line#"$word"}"
+	done
+	local cur="${words[-1]}"
+	local part="${cur##*[=:]}"
+	local trim="${cur%"$part"}"
 	while IFS= read -r c; do
-		COMPREPLY+=("${c%%$'\t'*}")
-	done <<< "$('a+a_-A0\'' --_complete ${COMP_WORDS[@]:1:$iprev} "$cur")"
+		[[ -z "$c" ]] && continue
+		if [[ "$c" == $'\t'* ]]; then
+			local directive=(${c#$'\t'})
+			compopt -o filenames 2>/dev/null
+			case "${directive[0]}" in
+			dirs)
+				mapfile -t -O "${#COMPREPLY[@]}" COMPREPLY < <(compgen -d -- "$part");;
+			files)
+				if (( ${#directive[@]} == 1 )); then
+					mapfile -t -O "${#COMPREPLY[@]}" COMPREPLY < <(compgen -f -- "$part")
+				else
+					mapfile -t -O "${#COMPREPLY[@]}" COMPREPLY < <(compgen -d -- "$part")
+					for ext in "${directive[@]:1}"; do
+						mapfile -t -O "${#COMPREPLY[@]}" COMPREPLY < <(compgen -f -X "!*.$ext" -- "$part")
+					done
+				fi;;
+			esac
+			continue
+		fi
+		c="${c%%$'\t'*}"
+		COMPREPLY+=("${c#"$trim"}")
+	done <<< "$('a+a_-A0\'' --_complete "${words[@]}")"
 }
 complete -F _complete_charli_a_a_-A0_ 'a+a_-A0\''
 `
@@ -313,11 +844,204 @@ var wantFish = `
 function __fish_complete_charli_a_a_-A0_
 	set -l tokens (commandline -cop)
 	set -l cur (commandline -ct)
-	'a+a_-A0\'' --_complete $tokens[2..-1] "$cur"
+	for line in ('a+a_-A0\'' --_complete $tokens[2..-1] "$cur")
+		if string match -q \t'*' -- $line
+			set -l directive (string split ' ' -- (string sub -s 2 -- $line))
+			switch $directive[1]
+				case dirs
+					__fish_complete_directories "$cur"
+				case files
+					if set -q directive[2]
+						set -l pattern '(/|\.('(string join '|' -- $directive[2..-1])'))$'
+						for path in (__fish_complete_path "$cur")
+							if string match -q -r -- $pattern (string split -f1 \t -- $path)
+								echo $path
+							end
+						end
+					else
+						__fish_complete_path "$cur"
+					end
+			end
+		else
+			echo $line
+		end
+	end
 end
 complete -c 'a+a_-A0\'' -f -k -a '(__fish_complete_charli_a_a_-A0_)'
 `
 
+var wantZsh = `
+#compdef a+a_-A0'
+_complete_charli_a_a_-A0_() {
+	local -a completions directive
+	local line word desc
+	while IFS= read -r line; do
+		[[ -z $line ]] && continue
+		if [[ $line == $'\t'* ]]; then
+			directive=(${=line#$'\t'})
+			continue
+		fi
+		word=${line%%$'\t'*}
+		desc=${line#*$'\t'}
+		word=${word//:/\\:}
+		if [[ $line == *$'\t'* && -n $desc ]]; then
+			completions+=("$word:$desc")
+		else
+			completions+=("$word")
+		fi
+	done < <('a+a_-A0'\''' --_complete "${(@Q)words[2,CURRENT-1]}" "${(Q)PREFIX}")
+	_describe -V completions completions
+	case $directive[1] in
+	dirs) _files -/ ;;
+	files)
+		if (( $#directive > 1 )); then
+			_files -g "*.(${(j:|:)directive[2,-1]})"
+		else
+			_files
+		fi ;;
+	esac
+}
+if [[ $zsh_eval_context[-1] == loadautofunc ]]; then
+	_complete_charli_a_a_-A0_ "$@"
+else
+	compdef _complete_charli_a_a_-A0_ 'a+a_-A0'\'''
+fi
+`
+
+var wantPowerShell = `
+Register-ArgumentCompleter -Native -CommandName 'a+a_-A0''' -ScriptBlock {
+	param($wordToComplete, $commandAst, $cursorPosition)
+	$words = @($commandAst.CommandElements |
+		Where-Object { $_.Extent.EndOffset -lt $cursorPosition } |
+		Select-Object -Skip 1 |
+		ForEach-Object {
+			if ($_ -is [System.Management.Automation.Language.StringConstantExpressionAst]) {
+				$_.Value
+			} else {
+				$_.ToString()
+			}
+		})
+	$words += $wordToComplete
+	$passing = Get-Variable PSNativeCommandArgumentPassing -ValueOnly -ErrorAction Ignore
+	if (-not $passing -or $passing -eq 'Legacy') {
+		$words = @($words | ForEach-Object { if ($_ -eq '') { '""' } else { $_ } })
+	}
+	& 'a+a_-A0''' --_complete @words | ForEach-Object {
+		$word, $desc = $_ -split "` + "`" + `t", 2
+		if (-not $word) { return }
+		if (-not $desc) { $desc = $word }
+		$text = $word
+		if ($word -match '[\s''"` + "`" + `$&(){};,|@#<>]') {
+			$text = "'" + ($word -replace "'", "''") + "'"
+		}
+		$type = 'ParameterValue'
+		if ($word.StartsWith('-')) { $type = 'ParameterName' }
+		[System.Management.Automation.CompletionResult]::new(
+			$text, $word, $type, $desc
+		)
+	}
+}
+`
+
+var wantNushell = `
+def "nu-complete charli a_a_-A0_" [context: string] {
+	let args = ($context | split row -r '\s+' | skip 1)
+	let lines = (^"a+a_-A0'" --_complete ...$args | lines | where $it != "")
+	if ($lines | any {|line| $line | str starts-with "\t" }) {
+		return null
+	}
+	$lines | each {|line|
+		let parts = ($line | split row -n 2 "\t")
+		let desc = if ($parts | length) > 1 { $parts.1 } else { "" }
+		{value: $parts.0, description: $desc}
+	}
+}
+extern "a+a_-A0'" [
+	...args: string@"nu-complete charli a_a_-A0_"
+]
+`
+
+var wantElvish = `
+use str
+set edit:completion:arg-completer['a+a_-A0'''] = {|@words|
+	var args = $words[1..]
+	(external 'a+a_-A0''') --_complete $@args | from-lines | each {|line|
+		if (==s $line '') { continue }
+		if (str:has-prefix $line "\t") {
+			edit:complete-filename $words[-1]
+			continue
+		}
+		var parts = [(str:split &max=2 "\t" $line)]
+		if (and (> (count $parts) 1) (!=s $parts[1] '')) {
+			edit:complex-candidate $parts[0] &display=$parts[0]' ('$parts[1]')'
+		} else {
+			edit:complex-candidate $parts[0]
+		}
+	}
+}
+`
+
+func TestCompletePartialParse(t *testing.T) {
+	var calls []string
+	record := func(name string) func(string) error {
+		return func(string) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	var got *charli.Result
+	app := charli.App{
+		Commands: []charli.Command{
+			{
+				Options: []charli.Option{
+					{Long: "name", Validate: record("Validate"), Env: "NAME"},
+					{Long: "token", Required: true},
+					{Short: 'a', Flag: true},
+					{Short: 'b', Flag: true},
+					{
+						Long: "value",
+						Complete: func(r *charli.Result, cur string) []charli.Completion {
+							got = r
+							return nil
+						},
+					},
+				},
+				Constraints: []charli.Constraint{
+					{Kind: charli.AllOrNone, Options: []string{"a", "b"}},
+				},
+				Args: charli.Args{
+					Count:      1,
+					Validators: []func(string) error{record("Validators")},
+				},
+			},
+		},
+		LookupEnv: func(key string) (string, bool) {
+			calls = append(calls, "LookupEnv")
+			return "x", true
+		},
+	}
+
+	var buf bytes.Buffer
+	app.Complete(&buf, []string{
+		"program", "_c", "--name", "x", "-a", "arg", "--value", "",
+	})
+
+	if got == nil {
+		t.Fatal("completer wasn't called")
+	}
+	if len(calls) != 0 {
+		t.Errorf("callbacks were called while completing: %v", calls)
+	}
+	// Required options and constraints aren't checked.
+	if len(got.Errs) != 0 {
+		t.Errorf("unexpected errors: %v", got.Errs)
+	}
+	if got.Options["name"].Value != "x" || len(got.Args) != 1 {
+		t.Errorf("args weren't parsed: %v %v", got.Options["name"], got.Args)
+	}
+}
+
 func TestCompletionScripts(t *testing.T) {
 	// Use a really ugly name to test the identifiers + escaping.
 	program := "a+a_-A0'"
@@ -331,6 +1055,22 @@ func TestCompletionScripts(t *testing.T) {
 	charli.GenerateFishCompletions(&buf, program, flag)
 	gotFish := buf.String()
 
+	buf = bytes.Buffer{}
+	charli.GenerateZshCompletions(&buf, program, flag)
+	gotZsh := buf.String()
+
+	buf = bytes.Buffer{}
+	charli.GeneratePowerShellCompletions(&buf, program, flag)
+	gotPowerShell := buf.String()
+
+	buf = bytes.Buffer{}
+	charli.GenerateNushellCompletions(&buf, program, flag)
+	gotNushell := buf.String()
+
+	buf = bytes.Buffer{}
+	charli.GenerateElvishCompletions(&buf, program, flag)
+	gotElvish := buf.String()
+
 	tests := []struct {
 		name string
 		got  string
@@ -338,6 +1078,10 @@ func TestCompletionScripts(t *testing.T) {
 	}{
 		{"bash", gotBash, wantBash},
 		{"fish", gotFish, wantFish},
+		{"zsh", gotZsh, wantZsh},
+		{"powershell", gotPowerShell, wantPowerShell},
+		{"nushell", gotNushell, wantNushell},
+		{"elvish", gotElvish, wantElvish},
 	}
 
 	for _, test := range tests {
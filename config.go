@@ -5,10 +5,13 @@
 //
 // Configure your CLI with an [App]. Apps have one or several [Command] structs,
 // each with several [Option] structs and [Args].
+// Commands may also have nested subcommands.
 package charli
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/fatih/color"
 )
@@ -40,9 +43,13 @@ type App struct {
 	//  This is the second paragraph.
 	//  `
 	//
-	// The text won't be automatically justified when printed.
+	// Unless [App.HelpWidth] is set (or detected by [Result.PrintHelp]),
+	// the text won't be automatically justified when printed.
 	// Generally, it should be pre-justified at 78 characters
 	// (to make up for the 2-space indent).
+	// When it is wrapped, consecutive lines are joined into paragraphs,
+	// separated by blank lines. Indented lines (like code blocks) are left
+	// as they are.
 	//
 	// Text surrounded by {curly braces} will be highlighted.
 	Description string
@@ -71,6 +78,8 @@ type App struct {
 	// DefaultCommand is the name of the command to run if none is supplied.
 	// If blank, the parser will require a command.
 	//
+	// This must be the name of a top-level command (not a subcommand).
+	//
 	// Note that setting a default can introduce some ambiguity
 	// where the first supplied argument isn't an option
 	// (ie. it doesn't start with `-`).
@@ -94,6 +103,50 @@ type App struct {
 	// errors will be aggregated in [Result.Errs].
 	ErrorHandler func(error)
 
+	// GlobalConstraints configures [Constraint]s between options that apply
+	// to every command in [App.Commands].
+	// They are effectively prepended to every [Command.Constraints] slice.
+	GlobalConstraints []Constraint
+
+	// LookupEnv is used to read the environment variables named by
+	// [Option.Env].
+	//
+	// It has the same signature as [os.LookupEnv],
+	// which is usually what this should be set to.
+	// If nil, environment variables won't be read at all,
+	// so [App.Parse] depends on nothing but its arguments.
+	LookupEnv func(key string) (string, bool)
+
+	// CompleteFlag is a special trigger flag, *including* hyphen prefixes,
+	// used by completion scripts to request completions.
+	//
+	// If set, and the first argument supplied is this flag,
+	// [App.Parse] skips normal parsing and returns a [Result] with a
+	// [Complete] action. Your program must handle this action by calling
+	// [Result.PrintCompletions], or completion does nothing.
+	//
+	// This can be anything you want, but don't use anything ambiguous to your
+	// CLI. If in doubt, use "--_complete".
+	// It must be set to use [CompletionsCommand].
+	CompleteFlag string
+
+	// HelpWidth is the width, in columns, that [App.Help] wraps its output to.
+	//
+	// If this is 0, [App.Help] doesn't wrap anything,
+	// and descriptions are written verbatim.
+	// [Result.PrintHelp] will instead use the terminal's width,
+	// if stderr is a terminal.
+	// Set this to -1 to disable wrapping altogether.
+	HelpWidth int
+
+	// HelpSections configures the sections of help output, in order.
+	// See [HelpSection].
+	//
+	// If nil, [DefaultHelpSections] are used. To add sections (like
+	// `Examples`), append them to those.
+	// [Command.HelpSections] overrides this for command help.
+	HelpSections []HelpSection
+
 	// HighlightColor is the color used for highlighting in help output.
 	//
 	// To disable color, don't use this.
@@ -133,13 +186,22 @@ type Command struct {
 	//  This is the second paragraph.
 	//  `
 	//
-	// The text won't be automatically justified when printed.
+	// Unless [App.HelpWidth] is set (or detected by [Result.PrintHelp]),
+	// the text won't be automatically justified when printed.
 	// Generally, it should be pre-justified at 78 characters
 	// (to make up for the 2-space indent).
+	// When it is wrapped, consecutive lines are joined into paragraphs,
+	// separated by blank lines. Indented lines (like code blocks) are left
+	// as they are.
 	//
 	// Text surrounded by {curly braces} will be highlighted.
 	Description string
 
+	// HelpSections configures the sections of this command's help output,
+	// overriding [App.HelpSections].
+	// It doesn't apply to the help of any subcommands.
+	HelpSections []HelpSection
+
 	// Options is a slice of this command's unique [Option]s,
 	// in order of display in command help output.
 	// If [App.GlobalOptions] is set, those options will effectively be
@@ -151,11 +213,35 @@ type Command struct {
 	// configure.
 	Options []Option
 
+	// Constraints restrict how this command's options may be combined.
+	// See [Constraint].
+	//
+	// If [App.GlobalConstraints] is set, those constraints will effectively
+	// be prepended to this slice.
+	// If this command has subcommands,
+	// its constraints are inherited by them (as with its options).
+	Constraints []Constraint
+
 	// Args is the configuration for this command's positional arguments.
 	//
 	// If left blank, no positional arguments will be allowed.
 	Args Args
 
+	// Commands configures this command's subcommands,
+	// in the order they should be displayed in help output.
+	//
+	// If set, the user must supply one of these subcommands' names directly
+	// after this command's name (like `program remote add`).
+	// Subcommands may themselves have subcommands, to any depth.
+	//
+	// A command with subcommands can't be chosen by itself,
+	// so its [Command.Args] and [Command.Run] are unused.
+	// Its [Command.Options] are inherited by every subcommand,
+	// in the same way as [App.GlobalOptions].
+	//
+	// Subcommands must not have duplicate names.
+	Commands []Command
+
 	// Run is the function to execute if this Command is chosen.
 	//
 	// Supplying this function is actually entirely optional,
@@ -176,6 +262,10 @@ type Command struct {
 	// generally be with the caller,
 	// or you may wish to set [App.ErrorHandler].
 	Run func(r *Result)
+
+	// needsCompleteFlag is set by [CompletionsCommand],
+	// whose scripts require [App.CompleteFlag].
+	needsCompleteFlag bool
 }
 
 // An Option contains configuration for a single CLI option.
@@ -205,6 +295,20 @@ type Option struct {
 	// [OptionResult.IsSet] can be used to check whether the flag was supplied.
 	Flag bool
 
+	// Repeatable allows this option to be supplied more than once,
+	// like `-I dir1 -I dir2`.
+	//
+	// After parsing, in this option's [OptionResult],
+	// [OptionResult.Values] will contain every value supplied, in order,
+	// and [OptionResult.Count] will be the number of times it was supplied.
+	// This makes repeatable flags useful as counters: `-vvv` gives a count of
+	// 3.
+	//
+	// In help output, the option will be ellipsized (like `--tag TAG...`).
+	//
+	// If false, supplying the option more than once is an error.
+	Repeatable bool
+
 	// Choices constrains this option's values to a list.
 	//
 	// Available choices will be appended to the option's headline in help
@@ -214,6 +318,36 @@ type Option struct {
 	// This is invalid if set on flags.
 	Choices []string
 
+	// Validate is called by [App.Parse] to check each value supplied for this
+	// option (on the command line, or via [Option.Env]).
+	// It isn't called for [Option.Default] values.
+	//
+	// A returned error is reported as a [ValidationError],
+	// after all other parsing errors.
+	// Validation errors are reported in command-line order.
+	//
+	// Validate is only called once the value has passed any other checks
+	// (like [Option.Choices]). It is invalid if set on flags.
+	Validate func(value string) error
+
+	// Complete is called by [App.Complete] to suggest values for this option,
+	// in addition to any [Option.Choices].
+	//
+	// r is the result of parsing the command line before the option,
+	// which may contain errors. Validators aren't called and environment
+	// variables aren't read while parsing it, and required options and
+	// constraints aren't checked. cur is the partial value being completed.
+	// Candidates not starting with cur are discarded.
+	//
+	// It is invalid if set on flags.
+	Complete func(r *Result, cur string) []Completion
+
+	// Path marks this option's value as a file or directory path,
+	// so that shells fall back to completing paths.
+	//
+	// It is invalid if set on flags.
+	Path Path
+
 	// Metavar is the term for this option's value. It is shown in help output
 	// after the option name(s), like `VALUE` in `-o/--option VALUE`.
 	//
@@ -224,6 +358,42 @@ type Option struct {
 	// It is invalid if set with [Option.Flag].
 	Metavar string
 
+	// Required indicates that the user must supply this option
+	// (on the command line, or via [Option.Env]).
+	//
+	// In help output, required options are marked as such,
+	// and listed in the 'Usage:' line outside of `[OPTIONS]`.
+	//
+	// It is invalid if set with [Option.Flag] or [Option.Default].
+	Required bool
+
+	// Default is the value to use if this option isn't supplied
+	// (on the command line, or via [Option.Env]).
+	//
+	// After parsing, the default will be in [OptionResult.Value],
+	// but [OptionResult.IsSet] will remain false.
+	//
+	// The default will be shown alongside the option in help output.
+	//
+	// If [Option.Choices] is also set, the default must be one of them.
+	// It is invalid if set with [Option.Flag].
+	Default string
+
+	// Env is the name of an environment variable to read this option's value
+	// from, if the option isn't supplied on the command line.
+	// The variable will be shown alongside the option in help output.
+	//
+	// Environment variables are only read if [App.LookupEnv] is set.
+	// Empty variables are ignored.
+	//
+	// If set on a flag, the variable's value must be a boolean
+	// (as accepted by [strconv.ParseBool]),
+	// and the flag will only be set if it's true.
+	//
+	// [Option.Choices] apply to the variable's value as they would on the
+	// command line.
+	Env string
+
 	// Headline is a one-line summary of the option,
 	// shown in the 'Options:' section of help output.
 	//
@@ -261,8 +431,196 @@ type Args struct {
 	//     `[bracketed]`.
 	//   - The last metavar will be ellipsized, like `ARG...`.
 	Metavars []string
+
+	// Choices constrains the positional argument at the same index to a list,
+	// as [Option.Choices] does. Nil or empty lists are skipped.
+	//
+	// [App.Parse] reports an [InvalidChoiceError] for each argument that
+	// isn't one of its choices, in command-line order (among any other
+	// errors). Validators aren't called for these arguments.
+	//
+	// If [Args.Varadic] is true,
+	// arguments beyond the end of Choices use the last list.
+	//
+	// Choices are also suggested by [App.Complete].
+	Choices [][]string
+
+	// Validators are called by [App.Parse] to check the positional argument
+	// at the same index. Nil validators are skipped.
+	//
+	// If [Args.Varadic] is true,
+	// arguments beyond the end of Validators use the last validator.
+	//
+	// See [Option.Validate] for how errors are reported.
+	Validators []func(value string) error
+
+	// Completers are called by [App.Complete] to suggest values for the
+	// positional argument at the same index. Nil completers are skipped.
+	//
+	// If [Args.Varadic] is true,
+	// arguments beyond the end of Completers use the last completer.
+	//
+	// See [Option.Complete] for the meaning of r and cur.
+	Completers []func(r *Result, cur string) []Completion
+
+	// Paths marks the positional arguments at the same indices as file or
+	// directory paths. See [Option.Path].
+	//
+	// If [Args.Varadic] is true,
+	// arguments beyond the end of Paths use the last one.
+	Paths []Path
+}
+
+// metavar returns the metavar for the positional argument at index i.
+//
+// Omitted metavars default to `ARG`,
+// except for varadic args beyond those shown in help output,
+// which take the last metavar.
+func (args *Args) metavar(i int) string {
+	if i < len(args.Metavars) {
+		return args.Metavars[i]
+	}
+	if args.Varadic && len(args.Metavars) != 0 && i >= args.Count {
+		return args.Metavars[len(args.Metavars)-1]
+	}
+	return "ARG"
+}
+
+// choices returns the choices for the positional argument at index i,
+// which may be nil.
+func (args *Args) choices(i int) []string {
+	if i < len(args.Choices) {
+		return args.Choices[i]
+	}
+	if args.Varadic && len(args.Choices) != 0 {
+		return args.Choices[len(args.Choices)-1]
+	}
+	return nil
+}
+
+// validChoice returns true if value is valid for the positional argument at
+// index i, according to [Args.Choices].
+func (args *Args) validChoice(i int, value string) bool {
+	choices := args.choices(i)
+	return len(choices) == 0 || slices.Contains(choices, value)
 }
 
+// validator returns the validator for the positional argument at index i,
+// which may be nil.
+func (args *Args) validator(i int) func(string) error {
+	if i < len(args.Validators) {
+		return args.Validators[i]
+	}
+	if args.Varadic && len(args.Validators) != 0 {
+		return args.Validators[len(args.Validators)-1]
+	}
+	return nil
+}
+
+// completer returns the completer for the positional argument at index i,
+// which may be nil.
+func (args *Args) completer(i int) func(*Result, string) []Completion {
+	if i < len(args.Completers) {
+		return args.Completers[i]
+	}
+	if args.Varadic && len(args.Completers) != 0 {
+		return args.Completers[len(args.Completers)-1]
+	}
+	return nil
+}
+
+// path returns the [Path] for the positional argument at index i.
+func (args *Args) path(i int) Path {
+	if i < len(args.Paths) {
+		return args.Paths[i]
+	}
+	if args.Varadic && len(args.Paths) != 0 {
+		return args.Paths[len(args.Paths)-1]
+	}
+	return Path{}
+}
+
+// A Path marks a value as a file or directory path, for completion.
+//
+// [App.Complete] will direct the shell to complete paths natively,
+// alongside any other completions.
+type Path struct {
+	// Kind is the kind of path. The zero value indicates the value isn't a
+	// path.
+	Kind PathKind
+
+	// Extensions restricts [FilePath] completions to files with these
+	// extensions (without the leading dot, like `json`).
+	// Directories are still completed, so that the user can navigate into
+	// them.
+	Extensions []string
+}
+
+// PathKind indicates what kind of path a [Path] is.
+type PathKind uint8
+
+const (
+	NotPath  PathKind = iota // the value isn't a path
+	FilePath                 // the value is a path to a file (or directory)
+	DirPath                  // the value is a path to a directory
+)
+
+// directive returns the directive for this path in [App.Complete] output,
+// or a blank string if this isn't a path.
+func (p *Path) directive() string {
+	switch p.Kind {
+	case FilePath:
+		words := []string{"files"}
+		for _, ext := range p.Extensions {
+			words = append(words, strings.TrimPrefix(ext, "."))
+		}
+		return strings.Join(words, " ")
+	case DirPath:
+		return "dirs"
+	}
+	return ""
+}
+
+// A Completion is a value suggested by an [Option.Complete] or
+// [Args.Completers] func.
+type Completion struct {
+	Value       string // the value, which should start with the current word
+	Description string // a short description, shown by some shells
+}
+
+// A Constraint restricts how a set of options may be combined.
+//
+// Constraints are checked by [App.Parse] once all options have been supplied
+// (including via [Option.Env]).
+// Options supplied only by [Option.Default] don't count as supplied.
+// If any of a constraint's options were supplied with an invalid value
+// (like one that isn't in [Option.Choices]), the constraint isn't checked,
+// so that only the invalid value is reported.
+// (Values rejected by [Option.Validate] are still considered supplied.)
+//
+// In command help output, each constraint is described below the options.
+type Constraint struct {
+	// Kind is the kind of restriction. See [ConstraintKind].
+	Kind ConstraintKind
+
+	// Options is a list of the constrained options' names.
+	// These are the same as the keys of [Result.Options]:
+	// either [Option.Long] or [Option.Short], without hyphens.
+	//
+	// There must be at least 2 options.
+	Options []string
+}
+
+// ConstraintKind indicates the kind of restriction a [Constraint] applies.
+type ConstraintKind uint8
+
+const (
+	AtMostOne  ConstraintKind = iota // no more than one option may be supplied
+	ExactlyOne                       // one option must be supplied, but no more
+	AllOrNone                        // all options must be supplied, or none
+	Requires                         // the first option requires all the others
+)
+
 // HelpAccess indicates how help output should be accessed by the CLI user.
 // This is a bitmask.
 type HelpAccess uint8
@@ -280,15 +638,108 @@ func (app *App) hasHelpCommand() bool {
 	return app.HelpAccess&HelpCommand != 0
 }
 
-func (app *App) cmdMap() (m map[string]*Command) {
-	m = make(map[string]*Command, len(app.Commands))
-	for _, cmd := range app.Commands {
+// rootPath returns the command path implied before any args are parsed,
+// along with the commands that may be chosen from there.
+//
+// For single-command apps, the only command is implicitly chosen.
+func (app *App) rootPath() ([]*Command, []Command) {
+	if len(app.Commands) == 1 {
+		cmd := &app.Commands[0]
+		return []*Command{cmd}, cmd.Commands
+	}
+	return []*Command{}, app.Commands
+}
+
+// commandPath finds the chain of commands leading to cmd,
+// starting from the top level.
+//
+// Commands are matched by identity.
+// If cmd isn't part of the app's command tree,
+// it's treated as a top-level command.
+func (app *App) commandPath(cmd *Command) []*Command {
+	var find func(cmds []Command) []*Command
+	find = func(cmds []Command) []*Command {
+		for i := range cmds {
+			c := &cmds[i]
+			if c == cmd {
+				return []*Command{c}
+			}
+			if sub := find(c.Commands); sub != nil {
+				return append([]*Command{c}, sub...)
+			}
+		}
+		return nil
+	}
+
+	if path := find(app.Commands); path != nil {
+		return path
+	}
+	return []*Command{cmd}
+}
+
+// pathOptions aggregates the options available to the last command in path:
+// the global options, followed by the options of each command in path.
+func (app *App) pathOptions(path []*Command) []Option {
+	options := make([]Option, 0, len(app.GlobalOptions))
+	options = append(options, app.GlobalOptions...)
+	for _, cmd := range path {
+		options = append(options, cmd.Options...)
+	}
+	return options
+}
+
+// pathConstraints aggregates the constraints that apply to the last command in
+// path, in the same way as [App.pathOptions].
+func (app *App) pathConstraints(path []*Command) []Constraint {
+	constraints := make([]Constraint, 0, len(app.GlobalConstraints))
+	constraints = append(constraints, app.GlobalConstraints...)
+	for _, cmd := range path {
+		constraints = append(constraints, cmd.Constraints...)
+	}
+	return constraints
+}
+
+// pathNames returns the names of each command in path,
+// omitting the (unnamed) command of a single-command app.
+func pathNames(path []*Command) []string {
+	names := make([]string, 0, len(path))
+	for _, cmd := range path {
+		if cmd.Name != "" {
+			names = append(names, cmd.Name)
+		}
+	}
+	return names
+}
+
+// checkCommands panics if any level of the command tree has duplicate names.
+func checkCommands(cmds []Command) {
+	cmdMap(cmds)
+	for _, cmd := range cmds {
+		checkCommands(cmd.Commands)
+	}
+}
+
+// needsCompleteFlag returns true if any command in the tree was created by
+// [CompletionsCommand].
+func needsCompleteFlag(cmds []Command) bool {
+	for _, cmd := range cmds {
+		if cmd.needsCompleteFlag || needsCompleteFlag(cmd.Commands) {
+			return true
+		}
+	}
+	return false
+}
+
+func cmdMap(cmds []Command) (m map[string]*Command) {
+	m = make(map[string]*Command, len(cmds))
+	for i := range cmds {
+		cmd := &cmds[i]
 		if _, ok := m[cmd.Name]; ok {
 			panic(
 				fmt.Sprintf("Duplicate command '%s' configured", cmd.Name),
 			)
 		}
-		m[cmd.Name] = &cmd
+		m[cmd.Name] = cmd
 	}
 	return
 }
@@ -0,0 +1,205 @@
+package charli
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Fields maps options and positional arguments to the variables
+// (usually struct fields) that [Result.Decode] should store them in.
+//
+// Each target must be a pointer to one of the following types:
+//   - string: the raw value
+//   - []string: every value of an [Option.Repeatable] option, the single
+//     value of any other option, or every positional argument from this
+//     position onwards
+//   - bool: whether a flag is set, or see [OptionResult.Bool]
+//   - int: the [OptionResult.Count] of a repeatable flag,
+//     or see [OptionResult.Int]
+//   - uint: see [OptionResult.Uint]
+//   - float64: see [OptionResult.Float]
+//   - [time.Duration]: see [OptionResult.Duration]
+//   - [time.Time]: see [OptionResult.Time], using [time.RFC3339]
+//   - *[url.URL]: see [OptionResult.URL]
+//   - [netip.Addr]: see [OptionResult.IP]
+//
+// For other conversions (like [OptionResult.ByteSize]),
+// use the typed accessors directly.
+type Fields struct {
+	// Options maps [Result.Options] keys (like `port` or `p`) to targets.
+	Options map[string]any
+
+	// Args maps positions in [Result.Args] to targets.
+	// Use nil to skip a position.
+	Args []any
+}
+
+// Decode stores option and positional argument values in the targets
+// described by fields, converting them as the typed accessors do.
+//
+// Every conversion is attempted, so all invalid values are reported
+// (as [InvalidValueError]s, via [Result.Error]) in one pass.
+// Options are decoded in the order they're configured,
+// followed by positional arguments.
+//
+// Targets are left untouched if their option wasn't supplied
+// (and has no [Option.Default]), if their positional argument is absent,
+// or if their value is invalid. This means that targets can be
+// pre-populated with fallback values.
+//
+// Decode does nothing if options weren't parsed
+// (like when [Result.Action] is [Help]).
+//
+// Decode panics if fields names an unknown option, if it names the same
+// option twice (like by both `port` and `p`),
+// or if a target's type isn't supported.
+func (r *Result) Decode(fields Fields) {
+	if r.Options == nil {
+		return
+	}
+
+	seen := make(map[*OptionResult]string, len(fields.Options))
+	for name := range fields.Options {
+		o := r.Options[name]
+		if o == nil {
+			panic(fmt.Sprintf("Unknown option '%s' in Decode fields", name))
+		}
+		if other, ok := seen[o]; ok {
+			panic(fmt.Sprintf(
+				"Option '%s' is named twice in Decode fields, as '%s' and '%s'",
+				optionArg(o.Option),
+				min(name, other),
+				max(name, other),
+			))
+		}
+		seen[o] = name
+	}
+
+	// Iterate over the configured options, rather than the map,
+	// so that errors are reported in a stable order.
+	options := r.App.GlobalOptions
+	if r.Command != nil {
+		options = r.App.pathOptions(r.Path)
+	}
+	for i := range options {
+		option := &options[i]
+		for _, name := range []string{option.Long, string(option.Short)} {
+			target, ok := fields.Options[name]
+			if !ok || name == "" || name == "\x00" {
+				continue
+			}
+			r.decodeOption(r.Options[name], target)
+		}
+	}
+
+	for i, target := range fields.Args {
+		if target != nil {
+			r.decodeArg(i, target)
+		}
+	}
+}
+
+func (r *Result) decodeOption(o *OptionResult, target any) {
+	switch t := target.(type) {
+	case *string:
+		if o.IsSet || o.Value != "" {
+			*t = o.Value
+		}
+	case *[]string:
+		if o.Option.Repeatable && !o.Option.Flag {
+			if o.Values != nil {
+				*t = append([]string(nil), o.Values...)
+			} else if o.Value != "" {
+				*t = []string{o.Value}
+			}
+		} else if o.IsSet || o.Value != "" {
+			*t = []string{o.Value}
+		}
+	case *bool:
+		if o.Option.Flag {
+			if o.IsSet {
+				*t = true
+			}
+		} else {
+			assign(t)(convertOption(r, o, "true or false", strconv.ParseBool))
+		}
+	case *int:
+		if o.Option.Flag && o.Option.Repeatable {
+			if o.IsSet {
+				*t = o.Count
+			}
+		} else {
+			assign(t)(convertOption(r, o, "an integer", strconv.Atoi))
+		}
+	case *uint:
+		assign(t)(convertOption(r, o, "a non-negative integer", parseUint))
+	case *float64:
+		assign(t)(convertOption(r, o, "a number", parseFloat))
+	case *time.Duration:
+		assign(t)(convertOption(r, o, "a duration (like 1h30m)", time.ParseDuration))
+	case *time.Time:
+		assign(t)(convertOption(
+			r, o, "a time like "+time.RFC3339, parseTime(time.RFC3339),
+		))
+	case **url.URL:
+		assign(t)(convertOption(r, o, "an absolute URL", parseURL))
+	case *netip.Addr:
+		assign(t)(convertOption(r, o, "an IP address", netip.ParseAddr))
+	default:
+		panic(fmt.Sprintf(
+			"Unsupported Decode target %T for option '%s'",
+			target,
+			optionArg(o.Option),
+		))
+	}
+}
+
+func (r *Result) decodeArg(i int, target any) {
+	switch t := target.(type) {
+	case *string:
+		if i < len(r.Args) {
+			*t = r.Args[i]
+		}
+	case *[]string:
+		if i < len(r.Args) {
+			*t = append([]string(nil), r.Args[i:]...)
+		}
+	case *bool:
+		assign(t)(convertArg(r, i, "true or false", strconv.ParseBool))
+	case *int:
+		assign(t)(convertArg(r, i, "an integer", strconv.Atoi))
+	case *uint:
+		assign(t)(convertArg(r, i, "a non-negative integer", parseUint))
+	case *float64:
+		assign(t)(convertArg(r, i, "a number", parseFloat))
+	case *time.Duration:
+		assign(t)(convertArg(r, i, "a duration (like 1h30m)", time.ParseDuration))
+	case *time.Time:
+		assign(t)(convertArg(
+			r, i, "a time like "+time.RFC3339, parseTime(time.RFC3339),
+		))
+	case **url.URL:
+		assign(t)(convertArg(r, i, "an absolute URL", parseURL))
+	case *netip.Addr:
+		assign(t)(convertArg(r, i, "an IP address", netip.ParseAddr))
+	default:
+		panic(fmt.Sprintf(
+			"Unsupported Decode target %T for argument %d",
+			target,
+			i,
+		))
+	}
+}
+
+// assign returns a func that stores v in dst if ok is true.
+// It's intended to be called with the results of convertOption/convertArg.
+func assign[T any](dst *T) func(v T, ok bool) {
+	return func(v T, ok bool) {
+		if ok {
+			*dst = v
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package charli_test
+
+import (
+	"net/netip"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/starriver/charli"
+)
+
+var testDecodeApp = charli.App{
+	GlobalOptions: []charli.Option{
+		{Short: 'v', Flag: true, Repeatable: true},
+	},
+	Commands: []charli.Command{
+		{
+			Options: []charli.Option{
+				{Short: 'n', Long: "name"},
+				{Long: "port", Default: "8080"},
+				{Long: "tag", Repeatable: true},
+				{Long: "dry-run", Flag: true},
+				{Long: "timeout"},
+				{Long: "url"},
+				{Long: "ip"},
+				{Long: "ratio"},
+				{Long: "unset"},
+			},
+			Args: charli.Args{
+				Count:    1,
+				Varadic:  true,
+				Metavars: []string{"COUNT", "FILE"},
+			},
+		},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	type settings struct {
+		Verbosity int
+		Name      string
+		Port      uint
+		Tags      []string
+		DryRun    bool
+		Timeout   time.Duration
+		URL       *url.URL
+		IP        netip.Addr
+		Ratio     float64
+		Unset     string
+		Count     int
+		Files     []string
+	}
+
+	r := testDecodeApp.Parse([]string{
+		"program",
+		"-vv",
+		"-n", "a",
+		"--tag", "x", "--tag=y",
+		"--dry-run",
+		"--timeout", "5s",
+		"--url", "https://example.com",
+		"--ip", "127.0.0.1",
+		"--ratio", "0.5",
+		"3", "f1", "f2",
+	})
+	if r.Fail {
+		t.Fatalf("unexpected parse failure: %v", r.Errs)
+	}
+
+	s := settings{Unset: "fallback"}
+	r.Decode(charli.Fields{
+		Options: map[string]any{
+			"v":       &s.Verbosity,
+			"name":    &s.Name,
+			"port":    &s.Port,
+			"tag":     &s.Tags,
+			"dry-run": &s.DryRun,
+			"timeout": &s.Timeout,
+			"url":     &s.URL,
+			"ip":      &s.IP,
+			"ratio":   &s.Ratio,
+			"unset":   &s.Unset,
+		},
+		Args: []any{&s.Count, &s.Files},
+	})
+	if r.Fail {
+		t.Fatalf("unexpected errors: %v", r.Errs)
+	}
+
+	if s.Verbosity != 2 {
+		t.Errorf("Verbosity: got %d", s.Verbosity)
+	}
+	if s.Name != "a" {
+		t.Errorf("Name: got %s", s.Name)
+	}
+	if s.Port != 8080 {
+		t.Errorf("Port: got %d", s.Port)
+	}
+	if !slices.Equal(s.Tags, []string{"x", "y"}) {
+		t.Errorf("Tags: got %v", s.Tags)
+	}
+	if !s.DryRun {
+		t.Error("DryRun: got false")
+	}
+	if s.Timeout != 5*time.Second {
+		t.Errorf("Timeout: got %v", s.Timeout)
+	}
+	if s.URL == nil || s.URL.Host != "example.com" {
+		t.Errorf("URL: got %v", s.URL)
+	}
+	if s.IP != netip.AddrFrom4([4]byte{127, 0, 0, 1}) {
+		t.Errorf("IP: got %v", s.IP)
+	}
+	if s.Ratio != 0.5 {
+		t.Errorf("Ratio: got %f", s.Ratio)
+	}
+	if s.Unset != "fallback" {
+		t.Errorf("Unset: got %s", s.Unset)
+	}
+	if s.Count != 3 {
+		t.Errorf("Count: got %d", s.Count)
+	}
+	if !slices.Equal(s.Files, []string{"f1", "f2"}) {
+		t.Errorf("Files: got %v", s.Files)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var s struct {
+		Port    int
+		Timeout time.Duration
+		IP      netip.Addr
+		Count   int
+	}
+	s.Port = 1
+
+	r := testDecodeApp.Parse([]string{
+		"program",
+		"--ip", "nope",
+		"--timeout=soon",
+		"--port", "http",
+		"x",
+	})
+	if r.Fail {
+		t.Fatalf("unexpected parse failure: %v", r.Errs)
+	}
+
+	r.Decode(charli.Fields{
+		Options: map[string]any{
+			"ip":      &s.IP,
+			"port":    &s.Port,
+			"timeout": &s.Timeout,
+		},
+		Args: []any{&s.Count},
+	})
+
+	// Errors are in configuration order, then positional order.
+	want := []string{
+		"invalid '--port http': must be an integer",
+		"invalid '--timeout=soon': must be a duration (like 1h30m)",
+		"invalid '--ip nope': must be an IP address",
+		"invalid COUNT 'x': must be an integer",
+	}
+	if len(r.Errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(r.Errs), len(want), r.Errs)
+	}
+	for i, err := range r.Errs {
+		if s := err.Error(); s != want[i] {
+			t.Errorf("got '%s', want '%s'", s, want[i])
+		}
+	}
+
+	if s.Port != 1 {
+		t.Errorf("invalid target should be untouched: got %d", s.Port)
+	}
+}
+
+func TestDecodePanic(t *testing.T) {
+	r := testDecodeApp.Parse([]string{"program", "1"})
+
+	expectPanic := func(t *testing.T) {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic")
+		}
+	}
+
+	t.Run("unknown option", func(t *testing.T) {
+		defer expectPanic(t)
+		var s string
+		r.Decode(charli.Fields{Options: map[string]any{"nope": &s}})
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		defer expectPanic(t)
+		var s int8
+		r.Decode(charli.Fields{Args: []any{&s}})
+	})
+
+	t.Run("aliased option", func(t *testing.T) {
+		defer expectPanic(t)
+		var a, b string
+		r.Decode(charli.Fields{Options: map[string]any{"name": &a, "n": &b}})
+	})
+}
+
+func TestDecodeHelp(t *testing.T) {
+	r := testDecodeApp.Parse([]string{"program", "--help"})
+	if r.Action != charli.Help {
+		t.Fatalf("expected Help action, got %v", r.Action)
+	}
+
+	// Options weren't parsed, so there's nothing to decode.
+	name := "fallback"
+	r.Decode(charli.Fields{Options: map[string]any{"name": &name}})
+	if name != "fallback" {
+		t.Errorf("target should be untouched: got %s", name)
+	}
+	if r.Fail {
+		t.Errorf("unexpected errors: %v", r.Errs)
+	}
+}
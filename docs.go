@@ -0,0 +1,409 @@
+package charli
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// A docSection documents the app or a single command, for [App.Markdown] and
+// [App.HTML].
+//
+// Apart from the title, usage and IDs, text fields use {curly brace}
+// highlighting, which each format converts to code spans.
+type docSection struct {
+	level       int
+	id          string
+	title       string
+	headline    string
+	usage       string
+	description string
+	options     []docOption
+	constraints []string
+	commands    []docCommand
+}
+
+type docOption struct {
+	names, metavar, choices, description string
+}
+
+type docCommand struct {
+	name, id, headline string
+}
+
+// docSections gathers a section for the app (or its single command), followed
+// by a section for every command, depth-first.
+// The sections contain the same information as [App.Help].
+func (app *App) docSections(program string) []docSection {
+	var sections []docSection
+
+	var add func(cmd *Command, level int)
+	add = func(cmd *Command, level int) {
+		var path []*Command
+		if cmd != nil {
+			path = app.commandPath(cmd)
+		}
+		root, _ := app.rootPath()
+		title := strings.Join(
+			append([]string{program}, pathNames(path[len(root):])...),
+			" ",
+		)
+
+		s := docSection{
+			level: level,
+			id:    docID(title),
+			title: title,
+		}
+
+		isGroup := cmd != nil && len(cmd.Commands) != 0
+
+		options := make([]Option, 0, 16)
+		if app.hasHelpFlags() {
+			options = append(options, fakeHelpOption)
+		}
+		var set *optionSet
+		if cmd == nil || !isGroup {
+			set = app.resolveOptions(path)
+			options = append(options, set.options...)
+		}
+
+		identity := func(s string) string { return s }
+		usage := app.usage(cmd, path, options, usageStyle{
+			command: identity,
+			option:  identity,
+			metavar: identity,
+		})
+		s.usage = strings.Join(append([]string{program}, usage...), " ")
+
+		if cmd == nil {
+			s.headline = app.Headline
+			s.description = app.Description
+		} else {
+			s.headline = cmd.Headline
+			if s.headline == "" && len(path) == len(root) {
+				s.headline = app.Headline
+			}
+			s.description = cmd.Description
+		}
+		s.description = trimDescription(s.description)
+
+		for i := range options {
+			option := &options[i]
+
+			var o docOption
+			var names []string
+			if option.Short != 0 {
+				names = append(names, "{-"+string(option.Short)+"}")
+			}
+			if option.Long != "" {
+				names = append(names, "{--"+option.Long+"}")
+			}
+			o.names = strings.Join(names, ", ")
+			if option.Repeatable {
+				o.names += "..."
+			}
+
+			if !option.Flag {
+				o.metavar = option.Metavar
+				if o.metavar == "" {
+					o.metavar = "VALUE"
+				}
+				o.metavar = "{" + o.metavar + "}"
+			}
+
+			choices := make([]string, len(option.Choices))
+			for i, c := range option.Choices {
+				choices[i] = "{" + c + "}"
+			}
+			o.choices = strings.Join(choices, ", ")
+
+			var description []string
+			if option.Headline != "" {
+				description = append(description, option.Headline)
+			}
+			if option.Required {
+				description = append(description, "(required)")
+			}
+			if option.Default != "" {
+				description = append(
+					description,
+					"(default: {"+option.Default+"})",
+				)
+			}
+			if option.Env != "" {
+				description = append(description, "(env: {"+option.Env+"})")
+			}
+			o.description = strings.Join(description, " ")
+
+			s.options = append(s.options, o)
+		}
+
+		if set != nil {
+			for _, c := range set.constraints {
+				args := make([]string, len(c.Options))
+				for i, name := range c.Options {
+					args[i] = "{" + optionArg(set.names[name]) + "}"
+				}
+
+				line := describeConstraint(&c, args)
+				s.constraints = append(s.constraints, line)
+			}
+		}
+
+		var cmds []Command
+		if cmd == nil {
+			cmds = app.Commands
+		} else {
+			cmds = cmd.Commands
+		}
+		for _, c := range cmds {
+			s.commands = append(s.commands, docCommand{
+				name:     c.Name,
+				id:       docID(title + " " + c.Name),
+				headline: c.Headline,
+			})
+		}
+
+		sections = append(sections, s)
+
+		for i := range cmds {
+			add(&cmds[i], min(level+1, 3))
+		}
+	}
+
+	// A single command is documented in the app's section.
+	if len(app.Commands) == 1 {
+		add(&app.Commands[0], 1)
+	} else {
+		add(nil, 1)
+	}
+
+	return sections
+}
+
+// docID returns a fragment ID for a section title,
+// in the same way that GitHub does for Markdown headings.
+func docID(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Markdown writes reference documentation for the app to w, as Markdown.
+//
+// program should be the name of the program.
+//
+// The document starts with a section for the app, followed by a section for
+// each command (including subcommands), in the order they're configured.
+// Each section contains the same information as [App.Help]:
+// the command's headline, a usage line, its description,
+// a table of options, and a table of subcommands (linking to their sections).
+//
+// Text surrounded by {curly braces} is formatted as code.
+func (app *App) Markdown(w io.Writer, program string) {
+	print := func(str string) {
+		fmt.Fprint(w, str)
+	}
+	printf := func(format string, a ...any) {
+		fmt.Fprintf(w, format, a...)
+	}
+
+	for i, s := range app.docSections(program) {
+		if i != 0 {
+			print("\n")
+		}
+		printf("%s %s\n", strings.Repeat("#", s.level), s.title)
+
+		if s.headline != "" {
+			printf("\n%s\n", markdownText(s.headline))
+		}
+
+		printf("\n```\n%s\n```\n", s.usage)
+
+		if s.description != "" {
+			print("\n")
+			for _, block := range textBlocks(s.description) {
+				switch block.kind {
+				case paragraphBlock, listItemBlock:
+					printf("%s\n", markdownText(block.text))
+				case codeBlock:
+					printf("```\n%s\n```\n", block.text)
+				case blankBlock:
+					print("\n")
+				}
+			}
+		}
+
+		if len(s.options) != 0 {
+			print("\n**Options:**\n\n")
+			print("| Option | Value | Choices | Description |\n")
+			print("| --- | --- | --- | --- |\n")
+			for _, o := range s.options {
+				printf(
+					"| %s | %s | %s | %s |\n",
+					markdownCell(o.names),
+					markdownCell(o.metavar),
+					markdownCell(o.choices),
+					markdownCell(o.description),
+				)
+			}
+
+			if len(s.constraints) != 0 {
+				print("\n")
+				for _, c := range s.constraints {
+					printf("- %s\n", markdownText(c))
+				}
+			}
+		}
+
+		if len(s.commands) != 0 {
+			print("\n**Commands:**\n\n")
+			print("| Command | Description |\n")
+			print("| --- | --- |\n")
+			for _, c := range s.commands {
+				printf(
+					"| [`%s`](#%s) | %s |\n",
+					c.name,
+					c.id,
+					markdownCell(c.headline),
+				)
+			}
+		}
+	}
+}
+
+// markdownText converts {curly brace} highlighting in s to code spans.
+func markdownText(s string) string {
+	return convertHighlights(
+		s,
+		func(s string) string { return s },
+		func(s string) string { return "`" + s + "`" },
+	)
+}
+
+// markdownCell formats s for a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(markdownText(s), "|", `\|`)
+}
+
+// HTML writes reference documentation for the app to w, as an HTML fragment
+// (without `<html>` or `<body>` tags), so that it can be embedded in a page.
+//
+// program should be the name of the program.
+//
+// The document contains the same sections as [App.Markdown].
+// Each heading has an `id`, which the command tables link to.
+//
+// Text surrounded by {curly braces} is formatted as `<code>`.
+func (app *App) HTML(w io.Writer, program string) {
+	print := func(str string) {
+		fmt.Fprint(w, str)
+	}
+	printf := func(format string, a ...any) {
+		fmt.Fprintf(w, format, a...)
+	}
+
+	for _, s := range app.docSections(program) {
+		printf(
+			"<h%d id=\"%s\">%s</h%d>\n",
+			s.level,
+			html.EscapeString(s.id),
+			html.EscapeString(s.title),
+			s.level,
+		)
+
+		if s.headline != "" {
+			printf("<p>%s</p>\n", htmlText(s.headline))
+		}
+
+		// Subheadings are one level below the section's heading.
+		sub := min(s.level+1, 6)
+
+		printf("<pre><code>%s</code></pre>\n", html.EscapeString(s.usage))
+
+		inList := false
+		for _, block := range textBlocks(s.description) {
+			if inList && block.kind != listItemBlock {
+				print("</ul>\n")
+				inList = false
+			}
+			switch block.kind {
+			case paragraphBlock:
+				printf("<p>%s</p>\n", htmlText(block.text))
+			case listItemBlock:
+				if !inList {
+					print("<ul>\n")
+					inList = true
+				}
+				printf("<li>%s</li>\n", htmlText(block.text[2:]))
+			case codeBlock:
+				printf(
+					"<pre><code>%s</code></pre>\n",
+					html.EscapeString(block.text),
+				)
+			}
+		}
+		if inList {
+			print("</ul>\n")
+		}
+
+		if len(s.options) != 0 {
+			printf("<h%d>Options</h%d>\n", sub, sub)
+			print("<table>\n<thead>\n<tr><th>Option</th><th>Value</th>")
+			print("<th>Choices</th><th>Description</th></tr>\n</thead>\n")
+			print("<tbody>\n")
+			for _, o := range s.options {
+				printf(
+					"<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+					htmlText(o.names),
+					htmlText(o.metavar),
+					htmlText(o.choices),
+					htmlText(o.description),
+				)
+			}
+			print("</tbody>\n</table>\n")
+
+			if len(s.constraints) != 0 {
+				print("<ul>\n")
+				for _, c := range s.constraints {
+					printf("<li>%s</li>\n", htmlText(c))
+				}
+				print("</ul>\n")
+			}
+		}
+
+		if len(s.commands) != 0 {
+			printf("<h%d>Commands</h%d>\n", sub, sub)
+			print("<table>\n<thead>\n")
+			print("<tr><th>Command</th><th>Description</th></tr>\n")
+			print("</thead>\n<tbody>\n")
+			for _, c := range s.commands {
+				printf(
+					"<tr><td><a href=\"#%s\"><code>%s</code></a></td>"+
+						"<td>%s</td></tr>\n",
+					html.EscapeString(c.id),
+					html.EscapeString(c.name),
+					htmlText(c.headline),
+				)
+			}
+			print("</tbody>\n</table>\n")
+		}
+	}
+}
+
+// htmlText escapes s for HTML, converting {curly brace} highlighting to
+// `<code>` elements.
+func htmlText(s string) string {
+	return convertHighlights(s, html.EscapeString, func(s string) string {
+		return "<code>" + html.EscapeString(s) + "</code>"
+	})
+}
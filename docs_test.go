@@ -0,0 +1,229 @@
+package charli_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/starriver/charli"
+)
+
+// testManApp (from man_test.go) is reused here.
+
+const testMarkdownOutput = `
+# tool
+
+Manage ` + "`tool`" + ` things
+
+` + "```" + `
+tool [OPTIONS] COMMAND [...]
+` + "```" + `
+
+Does things with ` + "`remotes`" + `.
+
+` + "```" + `
+  $ tool push --force
+` + "```" + `
+- Reads ` + "`FILE`" + ` if given
+
+**Options:**
+
+| Option | Value | Choices | Description |
+| --- | --- | --- | --- |
+| ` + "`-h`" + `, ` + "`--help`" + ` |  |  | Show this help |
+| ` + "`--dir`" + ` | ` + "`VALUE`" + ` |  | (default: ` + "`.`" + `) (env: ` + "`TOOL_DIR`" + `) |
+
+**Commands:**
+
+| Command | Description |
+| --- | --- |
+| [` + "`push`" + `](#tool-push) | Push to a remote |
+| [` + "`remote`" + `](#tool-remote) |  |
+
+## tool push
+
+Push to a remote
+
+` + "```" + `
+tool push [OPTIONS] --format FMT REMOTE
+` + "```" + `
+
+**Options:**
+
+| Option | Value | Choices | Description |
+| --- | --- | --- | --- |
+| ` + "`-h`" + `, ` + "`--help`" + ` |  |  | Show this help |
+| ` + "`--dir`" + ` | ` + "`VALUE`" + ` |  | (default: ` + "`.`" + `) (env: ` + "`TOOL_DIR`" + `) |
+| ` + "`-f`" + `, ` + "`--force`" + ` |  |  | Overwrite ` + "`REMOTE`" + `, like ` + "`-f`" + `/` + "`--force`" + ` |
+| ` + "`--format`" + ` | ` + "`FMT`" + ` | ` + "`json`" + `, ` + "`yaml`" + ` | (required) |
+
+- ` + "`--force`" + ` requires: ` + "`--format`" + `
+
+## tool remote
+
+` + "```" + `
+tool remote [OPTIONS] COMMAND [...]
+` + "```" + `
+
+**Options:**
+
+| Option | Value | Choices | Description |
+| --- | --- | --- | --- |
+| ` + "`-h`" + `, ` + "`--help`" + ` |  |  | Show this help |
+
+**Commands:**
+
+| Command | Description |
+| --- | --- |
+| [` + "`add`" + `](#tool-remote-add) | Add a remote |
+
+### tool remote add
+
+Add a remote
+
+` + "```" + `
+tool remote add [OPTIONS]
+` + "```" + `
+
+**Options:**
+
+| Option | Value | Choices | Description |
+| --- | --- | --- | --- |
+| ` + "`-h`" + `, ` + "`--help`" + ` |  |  | Show this help |
+| ` + "`--dir`" + ` | ` + "`VALUE`" + ` |  | (default: ` + "`.`" + `) (env: ` + "`TOOL_DIR`" + `) |
+`
+
+var testDocsEscapeApp = charli.App{
+	Commands: []charli.Command{
+		{
+			Headline:    "Compare <a> | <b>",
+			Description: "\nUses {a|b} & more.\n\n    if a < b {\n",
+			Options: []charli.Option{
+				{Long: "op", Choices: []string{"<", ">"}},
+			},
+		},
+	},
+	HelpAccess: charli.HelpCommand,
+}
+
+const testDocsEscapeMarkdown = `
+# cmp
+
+Compare <a> | <b>
+
+` + "```" + `
+cmp [OPTIONS]
+` + "```" + `
+
+Uses ` + "`a|b`" + ` & more.
+
+` + "```" + `
+    if a < b {
+` + "```" + `
+
+**Options:**
+
+| Option | Value | Choices | Description |
+| --- | --- | --- | --- |
+| ` + "`--op`" + ` | ` + "`VALUE`" + ` | ` + "`<`, `>`" + ` |  |
+`
+
+const testDocsEscapeHTML = `
+<h1 id="cmp">cmp</h1>
+<p>Compare &lt;a&gt; | &lt;b&gt;</p>
+<pre><code>cmp [OPTIONS]</code></pre>
+<p>Uses <code>a|b</code> &amp; more.</p>
+<pre><code>    if a &lt; b {</code></pre>
+<h2>Options</h2>
+<table>
+<thead>
+<tr><th>Option</th><th>Value</th><th>Choices</th><th>Description</th></tr>
+</thead>
+<tbody>
+<tr><td><code>--op</code></td><td><code>VALUE</code></td><td><code>&lt;</code>, <code>&gt;</code></td><td></td></tr>
+</tbody>
+</table>
+`
+
+// The description isn't surrounded by newlines.
+var testDocsShortApp = charli.App{
+	Commands:   []charli.Command{{Description: "Short text"}},
+	HelpAccess: charli.HelpCommand,
+}
+
+const testDocsShortMarkdown = `
+# cat
+
+` + "```" + `
+cat
+` + "```" + `
+
+Short text
+`
+
+const testDocsShortHTML = `
+<h1 id="cat">cat</h1>
+<pre><code>cat</code></pre>
+<p>Short text</p>
+`
+
+func TestDocs(t *testing.T) {
+	tests := []struct {
+		name   string
+		write  func(*bytes.Buffer)
+		output string
+	}{
+		{
+			name: "markdown",
+			write: func(buf *bytes.Buffer) {
+				testManApp.Markdown(buf, "tool")
+			},
+			output: testMarkdownOutput,
+		},
+		{
+			name: "markdown escaping",
+			write: func(buf *bytes.Buffer) {
+				testDocsEscapeApp.Markdown(buf, "cmp")
+			},
+			output: testDocsEscapeMarkdown,
+		},
+		{
+			name: "html escaping",
+			write: func(buf *bytes.Buffer) {
+				testDocsEscapeApp.HTML(buf, "cmp")
+			},
+			output: testDocsEscapeHTML,
+		},
+		{
+			name: "markdown short description",
+			write: func(buf *bytes.Buffer) {
+				testDocsShortApp.Markdown(buf, "cat")
+			},
+			output: testDocsShortMarkdown,
+		},
+		{
+			name: "html short description",
+			write: func(buf *bytes.Buffer) {
+				testDocsShortApp.HTML(buf, "cat")
+			},
+			output: testDocsShortHTML,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			test.write(&buf)
+
+			dmp := diffmatchpatch.New()
+			diffs := dmp.DiffMain(buf.String(), test.output[1:], false)
+
+			t.Log(dmp.DiffPrettyText(diffs))
+			for _, diff := range diffs {
+				if diff.Type != diffmatchpatch.DiffEqual {
+					t.Fail()
+				}
+			}
+		})
+	}
+}
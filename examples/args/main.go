@@ -34,8 +34,9 @@ func main() {
 		r.RunCommand()
 
 	case charli.Help:
-		// r.PrintHelp() is exactly equivalent to:
+		// r.PrintHelp() is equivalent to:
 		//   r.App.Help(os.Stderr, os.Args[0], r.Command)
+		// ...except that it wraps help to the terminal's width.
 		r.PrintHelp()
 
 	case charli.Fatal:
@@ -10,23 +10,19 @@ import (
 const description = `
 This example demos {charli}'s completions and script generation.
 
-To install the completions for bash and fish, run the {install} command.
+To install completions for your shell, run {completions install}.
 `
 
 var app = charli.App{
 	Description: description,
 	Commands: []charli.Command{
-		install,
+		charli.CompletionsCommand(charli.Completions{Program: "completions"}),
 		whatever,
 	},
+	CompleteFlag: "--_complete",
 }
 
 func main() {
-	if len(os.Args) > 1 && os.Args[1] == "--_complete" {
-		app.Complete(os.Stdout, os.Args)
-		return
-	}
-
 	r := app.Parse(os.Args)
 
 	switch r.Action {
@@ -37,10 +33,16 @@ func main() {
 		r.RunCommand()
 
 	case charli.Help:
-		// r.PrintHelp() is exactly equivalent to:
+		// r.PrintHelp() is equivalent to:
 		//   r.App.Help(os.Stderr, os.Args[0], r.Command)
+		// ...except that it wraps help to the terminal's width.
 		r.PrintHelp()
 
+	case charli.Complete:
+		// The completion scripts call the program with --_complete.
+		r.PrintCompletions()
+		return
+
 	case charli.Fatal:
 		// Fatal error, nothing else to do.
 	}
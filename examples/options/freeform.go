@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/starriver/charli"
 )
@@ -30,13 +29,11 @@ var freeform = charli.Command{
 			r.ErrorString("I need a name...")
 		}
 
-		var age int
-		if r.Options["age"].IsSet {
-			var err error
-			age, err = strconv.Atoi(r.Options["age"].Value)
-			if err != nil || age < 0 {
-				r.Errorf("%s? That ain't an age.", r.Options["age"].Value)
-			}
+		// Int(...) reports an error (and returns 0) if the value isn't an
+		// integer. If the option wasn't supplied, it returns 0 without error.
+		age := r.Options["age"].Int(r)
+		if age < 0 {
+			r.Errorf("%d? That ain't an age.", age)
 		}
 
 		if r.Fail {
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/starriver/charli"
+)
+
+const description = `
+This example demos {charli}'s nested subcommands. Try running {remote --help},
+then each of the {remote} subcommands.
+
+Note that this description is written as a raw multiline string, justified at
+80 characters, with a newline at each end. This is how charli expects it!
+`
+
+var app = charli.App{
+	Description: description,
+	Commands: []charli.Command{
+		remote,
+		{
+			Name:     "status",
+			Headline: "A top-level command, for comparison",
+			Run: func(r *charli.Result) {
+				if r.Fail {
+					return
+				}
+
+				fmt.Println("All quiet.")
+			},
+		},
+	},
+	HelpAccess: charli.HelpFlag | charli.HelpCommand,
+}
+
+func main() {
+	r := app.Parse(os.Args)
+
+	switch r.Action {
+	case charli.Proceed:
+		// r.RunCommand() is exactly equivalent to r.Command.Run(&r). The
+		// command's Run(...) func should provide further validation, then
+		// (if everything passed) actually do the work.
+		r.RunCommand()
+
+	case charli.Help:
+		// r.PrintHelp() is equivalent to:
+		//   r.App.Help(os.Stderr, os.Args[0], r.Command)
+		// ...except that it wraps help to the terminal's width.
+		r.PrintHelp()
+
+	case charli.Fatal:
+		// Fatal error, nothing else to do.
+	}
+
+	for _, err := range r.Errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	if r.Fail {
+		os.Exit(1)
+	}
+}
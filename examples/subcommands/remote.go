@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/starriver/charli"
+)
+
+const remoteDescription = `
+This command has subcommands, so it can't be run by itself. Its options are
+inherited by each subcommand, so {--dry-run} can be supplied to either.
+`
+
+var remote = charli.Command{
+	Name:        "remote",
+	Headline:    "Manage remotes",
+	Description: remoteDescription,
+	Options: []charli.Option{
+		{
+			Short:    'n',
+			Long:     "dry-run",
+			Flag:     true,
+			Headline: "Don't actually do anything",
+		},
+	},
+	Commands: []charli.Command{
+		{
+			Name:     "add",
+			Headline: "Add a remote",
+			Args: charli.Args{
+				Count:    2,
+				Metavars: []string{"NAME", "URL"},
+			},
+			Run: func(r *charli.Result) {
+				if r.Fail {
+					return
+				}
+
+				if r.Options["dry-run"].IsSet {
+					fmt.Print("(Not really) ")
+				}
+				fmt.Printf("Adding %s at %s\n", r.Args[0], r.Args[1])
+			},
+		},
+		{
+			Name:     "prune",
+			Headline: "Prune stale remotes",
+			Run: func(r *charli.Result) {
+				if r.Fail {
+					return
+				}
+
+				// r.Path contains each command chosen, from the top level down.
+				for _, cmd := range r.Path {
+					fmt.Printf("%s ", cmd.Name)
+				}
+				fmt.Println("- nothing to prune.")
+			},
+		},
+	},
+}
@@ -3,14 +3,15 @@ module github.com/starriver/charli
 go 1.22
 
 require (
-	github.com/adrg/xdg v0.5.0
 	github.com/fatih/color v1.17.0
 	github.com/go-test/deep v1.1.1
 	github.com/sergi/go-diff v1.3.1
+	golang.org/x/term v0.22.0
 )
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/stretchr/testify v1.9.0 // indirect
 	golang.org/x/sys v0.22.0 // indirect
 )
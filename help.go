@@ -30,223 +30,411 @@ var fakeHelpCmd = Command{
 //
 // If cmd is nil, global help will be written.
 // Otherwise, command help will be written.
+// If cmd is a subcommand, it should point into the app's command tree
+// (as [Result.Command] does),
+// so that the usage line can include its parent commands.
 //
-// The [App] Headline is written first, followed by a usage line.
-// For global help, the following are then written:
-//   - The [App] Description
-//   - A list of commands
-//   - A list of options
-//
-// For command help, the following are instead written:
-//   - The [Command] Description
+// Help is made up of [HelpSection]s, separated by blank lines.
+// They're taken from [Command.HelpSections] or [App.HelpSections],
+// defaulting to [DefaultHelpSections]:
+//   - The [App] Headline, followed by a usage line
+//   - For command help, the [Command] Headline and Description.
+//     Otherwise, the [App] Description.
 //   - A list of options
+//   - A list of commands (or subcommands, if the command has any)
 func (app *App) Help(w io.Writer, program string, cmd *Command) {
-	print := func(str string) {
-		fmt.Fprint(w, str)
+	h := app.helpContext(program, cmd)
+
+	sections := app.HelpSections
+	if cmd != nil && cmd.HelpSections != nil {
+		sections = cmd.HelpSections
+	}
+	if sections == nil {
+		sections = DefaultHelpSections()
+	}
+
+	var blocks []string
+	for _, s := range sections {
+		if block := s.render(h); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+
+	fmt.Fprintf(w, "%s\n", strings.Join(blocks, "\n\n"))
+}
+
+// A HelpSection is a section of help output, like the list of options.
+// See [App.Help].
+type HelpSection struct {
+	// Title is written (in bold, followed by a colon) on the first line of
+	// the section, like `Options:`. If blank, no title is written.
+	Title string
+
+	// Text is the section's text, like `Examples` or `Exit Status`.
+	// It's written in the same way as [Command.Description],
+	// so it may start and end with a newline `\n` (which are removed),
+	// and text surrounded by {curly braces} will be highlighted.
+	Text string
+
+	// Render, if set, returns the section's text instead,
+	// given the help being written.
+	// The text shouldn't end with a newline.
+	//
+	// If it returns a blank string, the section (including its title)
+	// is omitted.
+	Render func(h *HelpContext) string
+}
+
+func (s *HelpSection) render(h *HelpContext) string {
+	var body string
+	if s.Render != nil {
+		body = s.Render(h)
+	} else if s.Text != "" {
+		body = h.Text(s.Text)
+	}
+
+	if body == "" || s.Title == "" {
+		return body
 	}
-	printf := func(format string, a ...any) {
-		fmt.Fprintf(w, format, a...)
+	return h.Bold(s.Title+":") + "\n" + body
+}
+
+// DefaultHelpSections returns the sections written by [App.Help] by default.
+//
+// To add sections, append or insert them into the returned slice.
+func DefaultHelpSections() []HelpSection {
+	return []HelpSection{
+		UsageHelpSection,
+		DescriptionHelpSection,
+		OptionsHelpSection,
+		CommandsHelpSection,
 	}
+}
+
+var (
+	// UsageHelpSection contains the [App] Headline and a usage line.
+	UsageHelpSection = HelpSection{Render: (*HelpContext).usage}
 
+	// DescriptionHelpSection contains the [Command] Headline and Description
+	// in command help, or the [App] Description in global help.
+	DescriptionHelpSection = HelpSection{Render: (*HelpContext).description}
+
+	// OptionsHelpSection lists the available options, followed by a
+	// description of any constraints between them.
+	OptionsHelpSection = HelpSection{
+		Title:  "Options",
+		Render: (*HelpContext).options,
+	}
+
+	// CommandsHelpSection lists the available commands in global help,
+	// or subcommands in command help.
+	CommandsHelpSection = HelpSection{
+		Title:  "Commands",
+		Render: (*HelpContext).commands,
+	}
+)
+
+// A HelpContext describes the help being written by [App.Help],
+// for [HelpSection.Render] funcs.
+type HelpContext struct {
+	App *App
+
+	// Program is the base name of the program.
+	Program string
+
+	// Command is the command that help is being written for,
+	// or nil for global help. Path is its path, as in [Result.Path].
+	Command *Command
+	Path    []*Command
+
+	// Options are the options listed in help output,
+	// including the help flags.
+	Options []Option
+
+	// Width is the width that text should be wrapped to,
+	// or 0 if it shouldn't be wrapped. See [App.HelpWidth].
+	Width int
+
+	// Commands with subcommands are displayed much like global help.
+	isGroup bool
+
+	// set is nil for global help, or for commands with subcommands.
+	set *optionSet
+
+	hi, bold func(string, ...any) string
+	grey     func(...any) string
+}
+
+func (app *App) helpContext(program string, cmd *Command) *HelpContext {
 	// Default to blue.
 	hiColor := app.HighlightColor
 	if hiColor == 0 {
 		hiColor = color.FgHiBlue
 	}
 
-	hi := color.New(hiColor).SprintfFunc()
-	bold := color.New(color.Bold).SprintfFunc()
-	grey := color.New(color.Faint).SprintFunc()
+	h := &HelpContext{
+		App:     app,
+		Program: filepath.Base(program),
+		Command: cmd,
+		hi:      color.New(hiColor).SprintfFunc(),
+		bold:    color.New(color.Bold).SprintfFunc(),
+		grey:    color.New(color.Faint).SprintFunc(),
+	}
 
-	// Applys color to {FOO} and {-f/--foo} in descriptions.
-	re := regexp.MustCompile(`\{.+?\}`)
-	highlight := func(d string) string {
-		return re.ReplaceAllStringFunc(d, func(s string) string {
-			slashIndex := strings.Index(s, "/")
-			if slashIndex != -1 {
-				return hi(s[1:slashIndex]) +
-					grey("/") +
-					hi(s[slashIndex+1:len(s)-1])
-			}
-			return hi(s[1 : len(s)-1])
-		})
+	// Text is only wrapped if a width is set.
+	if app.HelpWidth > 0 {
+		h.Width = app.HelpWidth
 	}
 
+	if cmd != nil {
+		h.Path = app.commandPath(cmd)
+	}
+
+	h.isGroup = cmd != nil && len(cmd.Commands) != 0
+
 	// Aggregate all options now -
 	// we need to know whether to print [OPTIONS] in the usage line.
 	// Capacity 16 is a naive guess.
-	options := make([]Option, 0, 16)
+	h.Options = make([]Option, 0, 16)
 	if app.hasHelpFlags() {
-		options = append(options, fakeHelpOption)
+		h.Options = append(h.Options, fakeHelpOption)
 	}
-	if cmd != nil {
-		options = append(options, app.GlobalOptions...)
-		options = append(options, cmd.Options...)
+	if cmd != nil && !h.isGroup {
+		h.set = app.resolveOptions(h.Path)
+		h.Options = append(h.Options, h.set.options...)
 	}
 
-	if app.Headline != "" {
-		printf("%s\n", highlight(app.Headline))
-	}
+	return h
+}
 
-	basename := filepath.Base(program)
-	printf("%s %s", bold("Usage:"), basename)
+// Highlight applies color to {FOO} and {-f/--foo} in text.
+func (h *HelpContext) Highlight(text string) string {
+	return highlightRe.ReplaceAllStringFunc(text, func(s string) string {
+		slashIndex := strings.Index(s, "/")
+		if slashIndex != -1 {
+			return h.hi(s[1:slashIndex]) +
+				h.grey("/") +
+				h.hi(s[slashIndex+1:len(s)-1])
+		}
+		return h.hi(s[1 : len(s)-1])
+	})
+}
 
-	var description string
+// Bold makes s bold.
+func (h *HelpContext) Bold(s string) string {
+	return h.bold(s)
+}
 
-	if cmd == nil {
-		cmdStr := hi("COMMAND")
-		if app.DefaultCommand != "" {
-			cmdStr = fmt.Sprintf("[%s]", cmdStr)
-		}
-		if len(options) != 0 {
-			printf(" [%s]", hi("OPTIONS"))
-		}
-		printf(" %s [...]", cmdStr)
+// Text formats text in the same way as [Command.Description]:
+// a single leading and trailing newline `\n` are removed (if present),
+// then each line is indented by 2 spaces, text surrounded by {curly braces} is
+// highlighted, and the text is wrapped if [HelpContext.Width] is set.
+func (h *HelpContext) Text(text string) string {
+	if text == "" {
+		return ""
+	}
+	text = h.Highlight(trimDescription(text))
+	if h.Width > 0 {
+		return reflow(text, 2, h.Width)
+	}
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ")
+}
 
-		description = app.Description
-	} else {
-		if len(app.Commands) != 1 {
-			if cmd.Name == app.DefaultCommand {
-				printf(" [%s]", cmd.Name)
-			} else {
-				printf(" %s", cmd.Name)
-			}
-		}
+func (h *HelpContext) usage() string {
+	var b strings.Builder
 
-		if len(options) != 0 {
-			printf(" [%s]", hi("OPTIONS"))
+	if h.App.Headline != "" {
+		headline := h.Highlight(h.App.Headline)
+		if h.Width > 0 {
+			headline = wrap(headline, 0, 0, h.Width)
 		}
+		fmt.Fprintf(&b, "%s\n", headline)
+	}
 
-		args := &cmd.Args
+	fmt.Fprintf(&b, "%s %s", h.bold("Usage:"), h.Program)
 
-		argsShown := max(args.Count, len(args.Metavars))
-		for i := range argsShown {
-			metavar := "ARG"
-			if i < len(args.Metavars) {
-				metavar = args.Metavars[i]
-			}
+	for _, word := range h.App.usage(h.Command, h.Path, h.Options, usageStyle{
+		command: func(s string) string { return s },
+		option:  func(s string) string { return h.hi(s) },
+		metavar: func(s string) string { return h.hi(s) },
+	}) {
+		fmt.Fprintf(&b, " %s", word)
+	}
 
-			// Is this an optional arg?
-			if i >= args.Count {
-				ellipsis := ""
-				if args.Varadic && (i == argsShown-1) {
-					ellipsis = "..."
-				}
-				printf(" [%s%s]", hi(metavar), ellipsis)
-			} else {
-				printf(" %s", hi(metavar))
-			}
-		}
+	return b.String()
+}
 
+func (h *HelpContext) description() string {
+	var blocks []string
+
+	description := h.App.Description
+	if cmd := h.Command; cmd != nil {
 		if cmd.Headline != "" {
-			printf("\n\n  %s", bold(cmd.Headline))
+			headline := h.bold(cmd.Headline)
+			if h.Width > 0 {
+				headline = wrap(headline, 2, 2, h.Width)
+			}
+			blocks = append(blocks, "  "+headline)
 		}
 
 		description = cmd.Description
 	}
 
 	if description != "" {
-		description = description[1 : len(description)-1]
-		description = strings.ReplaceAll(description, "\n", "\n  ")
-		description = highlight(description)
-		printf("\n\n  %s", description)
+		blocks = append(blocks, h.Text(description))
 	}
 
-	// Set up a left-align. These aren't in the following block because we reuse
-	// these variables to render the command listing later.
+	return strings.Join(blocks, "\n\n")
+}
+
+func (h *HelpContext) options() string {
+	options := h.Options
+	if len(options) == 0 {
+		return ""
+	}
+
+	hi, grey := h.hi, h.grey
+
+	// Set up a left-align.
 	left := make([]string, len(options))
 	lengths := make([]int, len(options))
 	leftMax := 0
 
-	if len(options) != 0 {
-		printf("\n\n%s", bold("Options:"))
-
-		slash := grey("/")
+	slash := grey("/")
 
-		for i, option := range options {
-			l := 0
+	for i, option := range options {
+		l := 0
 
-			if option.Short != 0 {
-				left[i] += hi("-" + string(option.Short))
-				l += 2
-				if option.Long != "" {
-					left[i] += slash
-					l += 1
-				}
-			}
+		if option.Short != 0 {
+			left[i] += hi("-" + string(option.Short))
+			l += 2
 			if option.Long != "" {
-				left[i] += hi("--" + option.Long)
-				l += 2 + len(option.Long)
+				left[i] += slash
+				l += 1
 			}
+		}
+		if option.Long != "" {
+			left[i] += hi("--" + option.Long)
+			l += 2 + len(option.Long)
+		}
 
-			if !option.Flag {
-				metavar := option.Metavar
-				if metavar == "" {
-					metavar = "VALUE"
-				}
-				left[i] += " " + hi(metavar)
-				l += 1 + len(metavar)
+		if !option.Flag {
+			metavar := option.Metavar
+			if metavar == "" {
+				metavar = "VALUE"
 			}
+			left[i] += " " + hi(metavar)
+			l += 1 + len(metavar)
+		}
+
+		if option.Repeatable {
+			left[i] += "..."
+			l += 3
+		}
+
+		if l > leftMax {
+			leftMax = l
+		}
+		lengths[i] = l
+	}
 
-			if l > leftMax {
-				leftMax = l
+	// Add 2 more spaces of padding.
+	leftMax += 2
+
+	// These may be used repeatedly for choices.
+	pipe := grey("|")
+	bracketOpen := grey("[")
+	bracketClose := grey("]")
+
+	lines := make([]string, 0, len(options))
+
+	for i, str := range left {
+		line := "  " + str
+
+		option := &options[i]
+
+		// Everything to the right of the option name is space-separated.
+		var right []string
+		if option.Headline != "" {
+			right = append(right, h.Highlight(option.Headline))
+		}
+		if option.Required {
+			right = append(right, h.bold("(required)"))
+		}
+		if len(option.Choices) != 0 {
+			choices := make([]string, len(option.Choices))
+			for i, c := range option.Choices {
+				choices[i] = hi(c)
 			}
-			lengths[i] = l
+			right = append(
+				right,
+				bracketOpen+strings.Join(choices, pipe)+bracketClose,
+			)
+		}
+		if option.Default != "" {
+			right = append(
+				right,
+				grey("(default: ")+hi(option.Default)+grey(")"),
+			)
+		}
+		if option.Env != "" {
+			right = append(right, grey("(env: ")+hi(option.Env)+grey(")"))
 		}
 
-		// Add 2 more spaces of padding.
-		leftMax += 2
-
-		// These may be used repeatedly for choices.
-		pipe := grey("|")
-		bracketOpen := grey("[")
-		bracketClose := grey("]")
-
-		for i, str := range left {
-			printf("\n  %s", str)
-
-			option := &options[i]
-			hasHeadline := option.Headline != ""
-			hasChoices := len(option.Choices) != 0
-			if hasHeadline || hasChoices {
-				print(strings.Repeat(" ", leftMax-lengths[i]))
-
-				if hasHeadline {
-					print(highlight(option.Headline))
-					if hasChoices {
-						print(" ")
-					}
-				}
-				if hasChoices {
-					print(bracketOpen)
-					for i, c := range option.Choices {
-						print(hi(c))
-						if i != len(option.Choices)-1 {
-							print(pipe)
-						}
-					}
-					print(bracketClose)
-				}
+		if len(right) != 0 {
+			str := strings.Join(right, " ")
+			pad := leftMax - lengths[i]
+			if h.Width > 0 {
+				line += wrapColumn(str, pad, 2+leftMax, h.Width)
+			} else {
+				line += strings.Repeat(" ", pad) + str
 			}
 		}
 
+		lines = append(lines, line)
 	}
 
-	print("\n")
+	// Describe any constraints below the options.
+	var constraints []Constraint
+	if h.set != nil {
+		constraints = h.set.constraints
+	}
+	if len(constraints) != 0 {
+		lines = append(lines, "")
+	}
 
-	if cmd != nil {
-		return
+	for _, c := range constraints {
+		args := make([]string, len(c.Options))
+		for i, name := range c.Options {
+			args[i] = hi(optionArg(h.set.names[name]))
+		}
+
+		line := describeConstraint(&c, args)
+		if h.Width > 0 {
+			line = wrap(line, 2, 4, h.Width)
+		}
+		lines = append(lines, "  "+line)
 	}
 
-	printf("\n%s", bold("Commands:"))
+	return strings.Join(lines, "\n")
+}
 
-	cmds := app.Commands
-	if (app.HelpAccess & HelpCommand) != 0 {
-		cmds = append([]Command{fakeHelpCmd}, cmds...)
+func (h *HelpContext) commands() string {
+	var cmds []Command
+	if h.Command == nil {
+		cmds = h.App.Commands
+		if (h.App.HelpAccess & HelpCommand) != 0 {
+			cmds = append([]Command{fakeHelpCmd}, cmds...)
+		}
+	} else if h.isGroup {
+		cmds = h.Command.Commands
+	}
+	if len(cmds) == 0 {
+		return ""
 	}
 
-	lengths = make([]int, len(cmds))
-	leftMax = 0
+	lengths := make([]int, len(cmds))
+	leftMax := 0
 
 	for i, cmd := range cmds {
 		l := len(cmd.Name)
@@ -258,13 +446,152 @@ func (app *App) Help(w io.Writer, program string, cmd *Command) {
 
 	leftMax += 2
 
+	lines := make([]string, len(cmds))
+
 	for i, cmd := range cmds {
-		printf("\n  %s", hi(cmd.Name))
+		lines[i] = "  " + h.hi(cmd.Name)
 		if cmd.Headline != "" {
-			print(strings.Repeat(" ", leftMax-lengths[i]))
-			print(highlight(cmd.Headline))
+			headline := h.Highlight(cmd.Headline)
+			pad := leftMax - lengths[i]
+			if h.Width > 0 {
+				lines[i] += wrapColumn(headline, pad, 2+leftMax, h.Width)
+			} else {
+				lines[i] += strings.Repeat(" ", pad) + headline
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// usageStyle formats the parts of a usage line.
+type usageStyle struct {
+	command func(string) string // command names
+	option  func(string) string // option args, like `--opt`
+	metavar func(string) string // metavars and placeholders, like `COMMAND`
+}
+
+// usage returns the words of the usage line for cmd, following the program
+// name. If cmd is nil, the global usage line is returned.
+//
+// path should be cmd's path, and options should be the options listed in help
+// (including the help flags).
+func (app *App) usage(
+	cmd *Command,
+	path []*Command,
+	options []Option,
+	style usageStyle,
+) []string {
+	var words []string
+
+	if cmd == nil {
+		if len(options) != 0 {
+			words = append(words, "["+style.metavar("OPTIONS")+"]")
+		}
+		cmdStr := style.metavar("COMMAND")
+		if app.DefaultCommand != "" {
+			cmdStr = "[" + cmdStr + "]"
+		}
+		return append(words, cmdStr, "[...]")
+	}
+
+	for i, c := range path {
+		if i == 0 && len(app.Commands) == 1 {
+			continue
+		}
+		if i == 0 && c.Name == app.DefaultCommand {
+			words = append(words, "["+style.command(c.Name)+"]")
+		} else {
+			words = append(words, style.command(c.Name))
+		}
+	}
+
+	if len(options) != 0 {
+		words = append(words, "["+style.metavar("OPTIONS")+"]")
+	}
+
+	for _, option := range options {
+		if !option.Required {
+			continue
+		}
+
+		metavar := option.Metavar
+		if metavar == "" {
+			metavar = "VALUE"
+		}
+		metavar = style.metavar(metavar)
+		if option.Repeatable {
+			metavar += "..."
+		}
+		words = append(words, style.option(optionArg(&option)), metavar)
+	}
+
+	args := &cmd.Args
+
+	argsShown := max(args.Count, len(args.Metavars))
+	if len(cmd.Commands) != 0 {
+		words = append(words, style.metavar("COMMAND"), "[...]")
+		argsShown = 0
+	}
+	for i := range argsShown {
+		metavar := style.metavar(args.metavar(i))
+
+		// Is this an optional arg?
+		if i >= args.Count {
+			ellipsis := ""
+			if args.Varadic && (i == argsShown-1) {
+				ellipsis = "..."
+			}
+			words = append(words, "["+metavar+ellipsis+"]")
+		} else {
+			words = append(words, metavar)
 		}
 	}
 
-	print("\n")
+	return words
+}
+
+var highlightRe = regexp.MustCompile(`\{.+?\}`)
+
+// convertHighlights formats text, passing highlighted text (in {curly braces})
+// to code, and everything else to plain.
+//
+// As in [App.Help], {-f/--foo} is treated as two highlights.
+func convertHighlights(
+	text string,
+	plain func(string) string,
+	code func(string) string,
+) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range highlightRe.FindAllStringIndex(text, -1) {
+		b.WriteString(plain(text[last:m[0]]))
+		last = m[1]
+
+		parts := strings.SplitN(text[m[0]+1:m[1]-1], "/", 2)
+		for i, part := range parts {
+			if i != 0 {
+				b.WriteString(plain("/"))
+			}
+			b.WriteString(code(part))
+		}
+	}
+	b.WriteString(plain(text[last:]))
+	return b.String()
+}
+
+// describeConstraint describes c for help output, given its options formatted
+// as args (like `--opt`).
+func describeConstraint(c *Constraint, args []string) string {
+	switch c.Kind {
+	case AtMostOne:
+		return "At most one of: " + strings.Join(args, ", ")
+	case ExactlyOne:
+		return "Exactly one of: " + strings.Join(args, ", ")
+	case AllOrNone:
+		return "All or none of: " + strings.Join(args, ", ")
+	case Requires:
+		return args[0] + " requires: " + strings.Join(args[1:], ", ")
+	}
+	return ""
 }
@@ -3,6 +3,8 @@ package charli_test
 import (
 	"bytes"
 	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/fatih/color"
@@ -391,9 +393,207 @@ Commands:
   cmd2
 `
 
+// Nested subcommands
+var testHelpApp15 = charli.App{
+	Commands: []charli.Command{
+		{
+			Name:     "remote",
+			Headline: "Manage remotes",
+			Options: []charli.Option{
+				{
+					Long:     "dry-run",
+					Flag:     true,
+					Headline: "Inherited",
+				},
+			},
+			Commands: []charli.Command{
+				{
+					Name:     "add",
+					Headline: "Add a remote",
+					Args: charli.Args{
+						Count:    2,
+						Metavars: []string{"NAME", "URL"},
+					},
+				},
+				{
+					Name:     "prune",
+					Headline: "Prune remotes",
+				},
+			},
+		},
+		{
+			Name: "cmd2",
+		},
+	},
+}
+
+const testHelpOutput15 = `
+Usage: program remote [OPTIONS] COMMAND [...]
+
+  Manage remotes
+
+Options:
+  -h/--help  Show this help
+
+Commands:
+  add    Add a remote
+  prune  Prune remotes
+`
+
+const testHelpOutput16 = `
+Usage: program remote add [OPTIONS] NAME URL
+
+  Add a remote
+
+Options:
+  -h/--help  Show this help
+  --dry-run  Inherited
+`
+
+// Repeatable options
+var testHelpApp17 = charli.App{
+	Commands: []charli.Command{
+		{
+			Options: []charli.Option{
+				{
+					Short:      'v',
+					Flag:       true,
+					Repeatable: true,
+					Headline:   "Verbosity",
+				},
+				{
+					Long:       "tag",
+					Metavar:    "TAG",
+					Repeatable: true,
+					Headline:   "Tags",
+				},
+			},
+		},
+	},
+}
+
+const testHelpOutput17 = `
+Usage: program [OPTIONS]
+
+Options:
+  -h/--help     Show this help
+  -v...         Verbosity
+  --tag TAG...  Tags
+`
+
+// Environment variables & defaults
+var testHelpApp18 = charli.App{
+	Commands: []charli.Command{
+		{
+			Options: []charli.Option{
+				{
+					Long:     "token",
+					Env:      "TOKEN",
+					Headline: "API token",
+				},
+				{
+					Long:    "format",
+					Env:     "FORMAT",
+					Choices: []string{"json", "yaml"},
+					Default: "json",
+				},
+				{
+					Long:     "retries",
+					Default:  "3",
+					Headline: "How many times to retry",
+				},
+			},
+		},
+	},
+}
+
+const testHelpOutput18 = `
+Usage: program [OPTIONS]
+
+Options:
+  -h/--help        Show this help
+  --token VALUE    API token (env: TOKEN)
+  --format VALUE   [json|yaml] (default: json) (env: FORMAT)
+  --retries VALUE  How many times to retry (default: 3)
+`
+
+// Required options
+var testHelpApp19 = charli.App{
+	Commands: []charli.Command{
+		{
+			Options: []charli.Option{
+				{
+					Short:    'v',
+					Flag:     true,
+					Headline: "Verbose",
+				},
+				{
+					Short:    't',
+					Long:     "token",
+					Metavar:  "TOKEN",
+					Required: true,
+					Headline: "API token",
+				},
+				{
+					Short:      'I',
+					Required:   true,
+					Repeatable: true,
+				},
+			},
+			Args: charli.Args{
+				Count:    1,
+				Metavars: []string{"FILE"},
+			},
+		},
+	},
+}
+
+const testHelpOutput19 = `
+Usage: program [OPTIONS] --token TOKEN -I VALUE... FILE
+
+Options:
+  -h/--help         Show this help
+  -v                Verbose
+  -t/--token TOKEN  API token (required)
+  -I VALUE...       (required)
+`
+
+// Constraints
+var testHelpApp20 = charli.App{
+	Commands: []charli.Command{
+		{
+			Options: []charli.Option{
+				{Short: 'j', Long: "json", Flag: true},
+				{Short: 'y', Long: "yaml", Flag: true},
+				{Long: "cert", Metavar: "FILE"},
+				{Long: "key", Metavar: "FILE"},
+			},
+			Constraints: []charli.Constraint{
+				{Kind: charli.AtMostOne, Options: []string{"j", "yaml"}},
+				{Kind: charli.Requires, Options: []string{"cert", "key"}},
+			},
+		},
+	},
+}
+
+const testHelpOutput20 = `
+Usage: program [OPTIONS]
+
+Options:
+  -h/--help    Show this help
+  -j/--json
+  -y/--yaml
+  --cert FILE
+  --key FILE
+
+  At most one of: --json, --yaml
+  --cert requires: --key
+`
+
 var testHelpCases = []struct {
 	app    *charli.App
 	cmd    bool
+	subcmd []int
 	output string
 }{
 	{
@@ -461,6 +661,37 @@ var testHelpCases = []struct {
 		app:    &testHelpApp14,
 		output: testHelpOutput14,
 	},
+	{
+		app:    &testHelpApp15,
+		cmd:    true,
+		output: testHelpOutput15,
+	},
+	{
+		app:    &testHelpApp15,
+		cmd:    true,
+		subcmd: []int{0},
+		output: testHelpOutput16,
+	},
+	{
+		app:    &testHelpApp17,
+		cmd:    true,
+		output: testHelpOutput17,
+	},
+	{
+		app:    &testHelpApp18,
+		cmd:    true,
+		output: testHelpOutput18,
+	},
+	{
+		app:    &testHelpApp19,
+		cmd:    true,
+		output: testHelpOutput19,
+	},
+	{
+		app:    &testHelpApp20,
+		cmd:    true,
+		output: testHelpOutput20,
+	},
 }
 
 func TestHelp(t *testing.T) {
@@ -471,6 +702,9 @@ func TestHelp(t *testing.T) {
 			var cmd *charli.Command
 			if test.cmd {
 				cmd = &test.app.Commands[0]
+				for _, i := range test.subcmd {
+					cmd = &cmd.Commands[i]
+				}
 			}
 
 			var buf bytes.Buffer
@@ -491,3 +725,275 @@ func TestHelp(t *testing.T) {
 		})
 	}
 }
+
+var testHelpWrapApp = charli.App{
+	Headline: "A {headline} that's long enough to need wrapping",
+	Commands: []charli.Command{
+		{
+			Headline: "A command headline that's also long enough to wrap",
+			Description: `
+This paragraph is written on
+several short lines, which are joined and {rewrapped}.
+
+  $ program --this-code-block --stays-as-it-is
+- A list item, which is long enough to wrap
+- Another item
+`,
+			Options: []charli.Option{
+				{
+					Short:    'v',
+					Long:     "verbose",
+					Flag:     true,
+					Headline: "Print {lots} of extra output while running",
+				},
+				{
+					Long:     "output",
+					Metavar:  "FILE",
+					Headline: "Write output here",
+					Default:  "out.txt",
+				},
+			},
+			Constraints: []charli.Constraint{
+				{
+					Kind:    charli.AtMostOne,
+					Options: []string{"verbose", "output"},
+				},
+			},
+		},
+	},
+}
+
+var testHelpWrapCases = []struct {
+	width  int
+	output string
+}{
+	{
+		width: 40,
+		output: `
+A headline that's long enough to need
+wrapping
+Usage: program [OPTIONS]
+
+  A command headline that's also long
+  enough to wrap
+
+  This paragraph is written on several
+  short lines, which are joined and
+  rewrapped.
+
+    $ program --this-code-block --stays-as-it-is
+  - A list item, which is long enough to
+    wrap
+  - Another item
+
+Options:
+  -h/--help      Show this help
+  -v/--verbose   Print lots of extra
+                 output while running
+  --output FILE  Write output here
+                 (default: out.txt)
+
+  At most one of: --verbose, --output
+`,
+	},
+	{
+		// Too narrow for the options' right-hand column.
+		width: 30,
+		output: `
+A headline that's long enough
+to need wrapping
+Usage: program [OPTIONS]
+
+  A command headline that's
+  also long enough to wrap
+
+  This paragraph is written on
+  several short lines, which
+  are joined and rewrapped.
+
+    $ program --this-code-block --stays-as-it-is
+  - A list item, which is long
+    enough to wrap
+  - Another item
+
+Options:
+  -h/--help
+      Show this help
+  -v/--verbose
+      Print lots of extra
+      output while running
+  --output FILE
+      Write output here
+      (default: out.txt)
+
+  At most one of: --verbose,
+    --output
+`,
+	},
+}
+
+func TestHelpWrap(t *testing.T) {
+	ansi := regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+	for _, test := range testHelpWrapCases {
+		app := testHelpWrapApp
+		app.HelpWidth = test.width
+
+		// Highlighting shouldn't affect where lines are wrapped.
+		for _, noColor := range []bool{true, false} {
+			name := fmt.Sprintf("width %d, color %v", test.width, !noColor)
+			t.Run(name, func(t *testing.T) {
+				color.NoColor = noColor
+				defer func() { color.NoColor = true }()
+
+				var buf bytes.Buffer
+				app.Help(&buf, "program", &app.Commands[0])
+				got := ansi.ReplaceAllString(buf.String(), "")
+				want := test.output[1:]
+
+				dmp := diffmatchpatch.New()
+				diffs := dmp.DiffMain(got, want, false)
+
+				t.Log(dmp.DiffPrettyText(diffs))
+				for _, diff := range diffs {
+					if diff.Type != diffmatchpatch.DiffEqual {
+						t.Fail()
+					}
+				}
+			})
+		}
+	}
+}
+
+// Lists options' environment variables, if there are any.
+var testHelpEnvSection = charli.HelpSection{
+	Title: "Environment",
+	Render: func(h *charli.HelpContext) string {
+		var lines []string
+		for _, option := range h.Options {
+			if option.Env != "" {
+				lines = append(lines, "  "+option.Env)
+			}
+		}
+		return strings.Join(lines, "\n")
+	},
+}
+
+var testHelpSectionsApp = charli.App{
+	Headline: "Headline",
+	Commands: []charli.Command{
+		{
+			Name:     "build",
+			Headline: "Build things",
+			Options: []charli.Option{
+				{Long: "jobs", Metavar: "N", Env: "JOBS"},
+			},
+		},
+		{
+			Name:     "clean",
+			Headline: "Clean up",
+			HelpSections: []charli.HelpSection{
+				charli.UsageHelpSection,
+				{Title: "Exit Status", Text: "\n0 if anything was removed.\n"},
+				// Single-line text needn't be wrapped in newlines.
+				{Title: "Note", Text: "Use {-f}"},
+				{Title: "Short", Text: "x"},
+			},
+		},
+	},
+	HelpSections: append(
+		charli.DefaultHelpSections(),
+		testHelpEnvSection,
+		charli.HelpSection{
+			Title: "Examples",
+			Text:  "\n$ program build --jobs 4\n",
+		},
+	),
+}
+
+var testHelpSectionsCases = []struct {
+	cmd    int // -1 for global help
+	output string
+}{
+	{
+		// The environment section is omitted.
+		cmd: -1,
+		output: `
+Headline
+Usage: program [OPTIONS] COMMAND [...]
+
+Options:
+  -h/--help  Show this help
+
+Commands:
+  build  Build things
+  clean  Clean up
+
+Examples:
+  $ program build --jobs 4
+`,
+	},
+	{
+		cmd: 0,
+		output: `
+Headline
+Usage: program build [OPTIONS]
+
+  Build things
+
+Options:
+  -h/--help  Show this help
+  --jobs N   (env: JOBS)
+
+Environment:
+  JOBS
+
+Examples:
+  $ program build --jobs 4
+`,
+	},
+	{
+		// Overridden by the command.
+		cmd: 1,
+		output: `
+Headline
+Usage: program clean [OPTIONS]
+
+Exit Status:
+  0 if anything was removed.
+
+Note:
+  Use -f
+
+Short:
+  x
+`,
+	},
+}
+
+func TestHelpSections(t *testing.T) {
+	color.NoColor = true
+
+	for _, test := range testHelpSectionsCases {
+		t.Run(fmt.Sprintf("cmd %d", test.cmd), func(t *testing.T) {
+			app := &testHelpSectionsApp
+			var cmd *charli.Command
+			if test.cmd != -1 {
+				cmd = &app.Commands[test.cmd]
+			}
+
+			var buf bytes.Buffer
+			app.Help(&buf, "program", cmd)
+
+			dmp := diffmatchpatch.New()
+			diffs := dmp.DiffMain(buf.String(), test.output[1:], false)
+
+			t.Log(dmp.DiffPrettyText(diffs))
+			for _, diff := range diffs {
+				if diff.Type != diffmatchpatch.DiffEqual {
+					t.Fail()
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package charli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Completions configures the command returned by [CompletionsCommand].
+type Completions struct {
+	// Name is the command name. If blank, it defaults to `completions`.
+	Name string
+
+	// Program is the program name that completion scripts are generated for,
+	// which should be in the user's PATH.
+	// It's also used to name the installed scripts.
+	//
+	// If blank, it defaults to the base name of [os.Args][0].
+	Program string
+
+	// FS is the filesystem that completion scripts are installed to.
+	// If nil, the OS filesystem is used.
+	FS InstallFS
+
+	// LookupEnv is used to read the environment variables that determine
+	// where scripts are installed (like `HOME` and `XDG_DATA_HOME`),
+	// and `SHELL`.
+	//
+	// If nil, [os.LookupEnv] is used.
+	LookupEnv func(key string) (string, bool)
+
+	// Stdout is where scripts and messages are written.
+	// If nil, [os.Stdout] is used.
+	Stdout io.Writer
+}
+
+// An InstallFS is a writable filesystem, which [CompletionsCommand] installs
+// completion scripts to. Paths are native OS paths, as used by [os].
+type InstallFS interface {
+	MkdirAll(path string, perm fs.FileMode) error
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Remove(name string) error
+}
+
+type osFS struct{}
+
+func (osFS) MkdirAll(path string, perm fs.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// A shell describes how to generate and install completions for a shell.
+type shell struct {
+	name     string
+	generate func(w io.Writer, program, flag string)
+
+	// path returns where the script should be installed, given the user's
+	// XDG data & config directories.
+	path func(env func(string) string, data, config, program string) string
+
+	// note returns instructions to print after installing the script at
+	// path, if any are needed.
+	note func(path string) string
+}
+
+var shells = []shell{
+	{
+		name:     "bash",
+		generate: GenerateBashCompletions,
+		path: func(env func(string) string, data, _, program string) string {
+			dir := env("BASH_COMPLETION_USER_DIR")
+			if dir == "" {
+				dir = filepath.Join(data, "bash-completion")
+			}
+			return filepath.Join(dir, "completions", program)
+		},
+	},
+	{
+		name:     "fish",
+		generate: GenerateFishCompletions,
+		path: func(_ func(string) string, _, config, program string) string {
+			return filepath.Join(config, "fish", "completions", program+".fish")
+		},
+	},
+	{
+		name:     "zsh",
+		generate: GenerateZshCompletions,
+		path: func(_ func(string) string, data, _, program string) string {
+			return filepath.Join(data, "zsh", "site-functions", "_"+program)
+		},
+		note: func(path string) string {
+			return "Make sure its directory is in $fpath before compinit " +
+				"is called:\n  fpath=(" + quotePOSIX(filepath.Dir(path)) + " $fpath)"
+		},
+	},
+	{
+		name:     "powershell",
+		generate: GeneratePowerShellCompletions,
+		path: func(_ func(string) string, _, config, program string) string {
+			return filepath.Join(
+				config, "powershell", "completions", program+".ps1",
+			)
+		},
+		note: func(path string) string {
+			return "Dot-source it from your $PROFILE:\n  . " + quotePowerShell(path)
+		},
+	},
+	{
+		name:     "nushell",
+		generate: GenerateNushellCompletions,
+		path: func(_ func(string) string, _, config, program string) string {
+			return filepath.Join(config, "nushell", "completions", program+".nu")
+		},
+		note: func(path string) string {
+			return "Source it from your config.nu:\n  source " + quoteNu(path)
+		},
+	},
+	{
+		name:     "elvish",
+		generate: GenerateElvishCompletions,
+		path: func(_ func(string) string, _, config, program string) string {
+			return filepath.Join(
+				config, "elvish", "completions", program+".elv",
+			)
+		},
+		note: func(path string) string {
+			return "Evaluate it from your rc.elv:\n  eval (slurp < " +
+				quoteElvish(path) + ")"
+		},
+	},
+}
+
+// shellAliases maps executable names to shell names, where they differ.
+var shellAliases = map[string]string{
+	"pwsh": "powershell",
+	"nu":   "nushell",
+}
+
+func shellNames() []string {
+	names := make([]string, len(shells))
+	for i, s := range shells {
+		names[i] = s.name
+	}
+	return names
+}
+
+func findShell(name string) *shell {
+	if alias, ok := shellAliases[name]; ok {
+		name = alias
+	}
+	for i := range shells {
+		if shells[i].name == name {
+			return &shells[i]
+		}
+	}
+	return nil
+}
+
+// CompletionsCommand returns a [Command] that installs, uninstalls and prints
+// completion scripts for bash, fish, zsh, PowerShell, nushell and elvish:
+//
+//	program completions install [SHELL...]
+//	program completions uninstall [SHELL...]
+//	program completions print SHELL
+//
+// If no shells are supplied to install or uninstall, the user's `SHELL`
+// environment variable is used.
+//
+// Scripts are installed to per-user locations,
+// following the XDG base directory spec:
+//
+//   - bash: `$XDG_DATA_HOME/bash-completion/completions/PROGRAM`
+//     (or `$BASH_COMPLETION_USER_DIR/completions/PROGRAM`)
+//   - fish: `$XDG_CONFIG_HOME/fish/completions/PROGRAM.fish`
+//   - zsh: `$XDG_DATA_HOME/zsh/site-functions/_PROGRAM`
+//   - PowerShell: `$XDG_CONFIG_HOME/powershell/completions/PROGRAM.ps1`
+//   - nushell: `$XDG_CONFIG_HOME/nushell/completions/PROGRAM.nu`
+//   - elvish: `$XDG_CONFIG_HOME/elvish/completions/PROGRAM.elv`
+//
+// bash and fish load these automatically. For the other shells,
+// instructions for loading the script are printed after it's installed.
+//
+// The scripts call the program with [App.CompleteFlag], which must be set:
+// [App.Parse] panics if it isn't. See [App.CompleteFlag] for handling it.
+func CompletionsCommand(c Completions) Command {
+	if c.Name == "" {
+		c.Name = "completions"
+	}
+
+	shellArgs := Args{
+		Count:    1,
+		Metavars: []string{"SHELL"},
+		Choices:  [][]string{shellNames()},
+	}
+	optionalShellArgs := shellArgs
+	optionalShellArgs.Count = 0
+	optionalShellArgs.Varadic = true
+
+	return Command{
+		needsCompleteFlag: true,
+
+		Name:     c.Name,
+		Headline: "Manage shell completions",
+		Commands: []Command{
+			{
+				Name:     "install",
+				Headline: "Install completion scripts",
+				Args:     optionalShellArgs,
+				Run: func(r *Result) {
+					c.run(r, c.install)
+				},
+			},
+			{
+				Name:     "uninstall",
+				Headline: "Remove installed completion scripts",
+				Args:     optionalShellArgs,
+				Run: func(r *Result) {
+					c.run(r, c.uninstall)
+				},
+			},
+			{
+				Name:     "print",
+				Headline: "Print a completion script",
+				Args:     shellArgs,
+				Run: func(r *Result) {
+					c.run(r, c.print)
+				},
+			},
+		},
+	}
+}
+
+// run calls f for each shell supplied (or the user's shell).
+func (c *Completions) run(r *Result, f func(*Result, *shell, string)) {
+	if r.Fail {
+		return
+	}
+
+	program := c.Program
+	if program == "" {
+		program = filepath.Base(os.Args[0])
+	}
+
+	names := r.Args
+	if len(names) == 0 {
+		name := filepath.Base(c.getenv("SHELL"))
+		if findShell(name) == nil {
+			r.Errorf(
+				"no shell supplied, and $SHELL isn't one of [%s]",
+				strings.Join(shellNames(), "|"),
+			)
+			return
+		}
+		names = []string{name}
+	}
+
+	for _, name := range names {
+		f(r, findShell(name), program)
+	}
+}
+
+func (c *Completions) install(r *Result, s *shell, program string) {
+	path, err := c.scriptPath(s, program)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+
+	var buf bytes.Buffer
+	s.generate(&buf, program, r.App.CompleteFlag)
+
+	fsys := c.fs()
+	if err := fsys.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.Error(err)
+		return
+	}
+	if err := fsys.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		r.Error(err)
+		return
+	}
+
+	w := c.stdout()
+	fmt.Fprintf(w, "%s completions installed to: %s\n", s.name, path)
+	if s.note != nil {
+		fmt.Fprintln(w, s.note(path))
+	}
+}
+
+func (c *Completions) uninstall(r *Result, s *shell, program string) {
+	path, err := c.scriptPath(s, program)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+
+	err = c.fs().Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(c.stdout(), "%s completions aren't installed\n", s.name)
+		return
+	}
+	if err != nil {
+		r.Error(err)
+		return
+	}
+	fmt.Fprintf(c.stdout(), "%s completions removed from: %s\n", s.name, path)
+}
+
+func (c *Completions) print(r *Result, s *shell, program string) {
+	s.generate(c.stdout(), program, r.App.CompleteFlag)
+}
+
+// scriptPath returns the path that s's script should be installed to.
+func (c *Completions) scriptPath(s *shell, program string) (string, error) {
+	home := c.getenv("HOME")
+	data := c.getenv("XDG_DATA_HOME")
+	config := c.getenv("XDG_CONFIG_HOME")
+	if home == "" && (data == "" || config == "") {
+		return "", errors.New("can't find your home directory: $HOME isn't set")
+	}
+	if data == "" {
+		data = filepath.Join(home, ".local", "share")
+	}
+	if config == "" {
+		config = filepath.Join(home, ".config")
+	}
+
+	return s.path(c.getenv, data, config, program), nil
+}
+
+func (c *Completions) getenv(key string) string {
+	lookup := c.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	value, _ := lookup(key)
+	return value
+}
+
+func (c *Completions) fs() InstallFS {
+	if c.FS == nil {
+		return osFS{}
+	}
+	return c.FS
+}
+
+func (c *Completions) stdout() io.Writer {
+	if c.Stdout == nil {
+		return os.Stdout
+	}
+	return c.Stdout
+}
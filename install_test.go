@@ -0,0 +1,215 @@
+package charli_test
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/starriver/charli"
+)
+
+// memFS is an in-memory [charli.InstallFS].
+type memFS map[string]string
+
+func (m memFS) MkdirAll(path string, perm fs.FileMode) error {
+	m[path+"/"] = ""
+	return nil
+}
+
+func (m memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m[name] = string(data)
+	return nil
+}
+
+func (m memFS) Remove(name string) error {
+	if _, ok := m[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m, name)
+	return nil
+}
+
+func testInstallApp(fsys memFS, env map[string]string, out *bytes.Buffer) *charli.App {
+	return &charli.App{
+		Commands: []charli.Command{
+			charli.CompletionsCommand(charli.Completions{
+				Program: "prog",
+				FS:      fsys,
+				LookupEnv: func(key string) (string, bool) {
+					v, ok := env[key]
+					return v, ok
+				},
+				Stdout: out,
+			}),
+			{Name: "other"},
+		},
+		CompleteFlag: "--_complete",
+	}
+}
+
+func TestCompletionsCommand(t *testing.T) {
+	script := func(generate func(w io.Writer, program, flag string)) string {
+		var buf bytes.Buffer
+		generate(&buf, "prog", "--_complete")
+		return buf.String()
+	}
+
+	tests := []struct {
+		args    []string
+		env     map[string]string
+		fs      memFS
+		wantFS  memFS
+		wantOut string
+		wantErr string
+	}{
+		{
+			args: []string{"install", "bash", "fish"},
+			env:  map[string]string{"HOME": "/home/u"},
+			fs:   memFS{},
+			wantFS: memFS{
+				"/home/u/.local/share/bash-completion/completions/":     "",
+				"/home/u/.local/share/bash-completion/completions/prog": script(charli.GenerateBashCompletions),
+				"/home/u/.config/fish/completions/":                     "",
+				"/home/u/.config/fish/completions/prog.fish":            script(charli.GenerateFishCompletions),
+			},
+			wantOut: "bash completions installed to: " +
+				"/home/u/.local/share/bash-completion/completions/prog\n" +
+				"fish completions installed to: " +
+				"/home/u/.config/fish/completions/prog.fish\n",
+		},
+		{
+			// The shell defaults to $SHELL.
+			args: []string{"install"},
+			env: map[string]string{
+				"HOME":          "/home/u",
+				"XDG_DATA_HOME": "/data",
+				"SHELL":         "/usr/bin/zsh",
+			},
+			fs: memFS{},
+			wantFS: memFS{
+				"/data/zsh/site-functions/":      "",
+				"/data/zsh/site-functions/_prog": script(charli.GenerateZshCompletions),
+			},
+			wantOut: "zsh completions installed to: " +
+				"/data/zsh/site-functions/_prog\n" +
+				"Make sure its directory is in $fpath before compinit is called:\n" +
+				"  fpath=('/data/zsh/site-functions' $fpath)\n",
+		},
+		{
+			args: []string{"install"},
+			env: map[string]string{
+				"XDG_DATA_HOME":   "/data",
+				"XDG_CONFIG_HOME": "/config",
+				"SHELL":           "/usr/bin/pwsh",
+			},
+			fs: memFS{},
+			wantFS: memFS{
+				"/config/powershell/completions/":         "",
+				"/config/powershell/completions/prog.ps1": script(charli.GeneratePowerShellCompletions),
+			},
+			wantOut: "powershell completions installed to: " +
+				"/config/powershell/completions/prog.ps1\n" +
+				"Dot-source it from your $PROFILE:\n" +
+				"  . '/config/powershell/completions/prog.ps1'\n",
+		},
+		{
+			args:   []string{"install"},
+			env:    map[string]string{"HOME": "/home/u", "SHELL": "/bin/sh"},
+			fs:     memFS{},
+			wantFS: memFS{},
+			wantErr: "no shell supplied, and $SHELL isn't one of " +
+				"[bash|fish|zsh|powershell|nushell|elvish]",
+		},
+		{
+			args:    []string{"install", "bash"},
+			env:     map[string]string{},
+			fs:      memFS{},
+			wantFS:  memFS{},
+			wantErr: "can't find your home directory: $HOME isn't set",
+		},
+		{
+			args: []string{"uninstall", "elvish", "nushell"},
+			env:  map[string]string{"HOME": "/home/u"},
+			fs: memFS{
+				"/home/u/.config/elvish/completions/prog.elv": "x",
+			},
+			wantFS: memFS{},
+			wantOut: "elvish completions removed from: " +
+				"/home/u/.config/elvish/completions/prog.elv\n" +
+				"nushell completions aren't installed\n",
+		},
+		{
+			args:    []string{"print", "fish"},
+			env:     map[string]string{},
+			fs:      memFS{},
+			wantFS:  memFS{},
+			wantOut: script(charli.GenerateFishCompletions),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			var out bytes.Buffer
+			app := testInstallApp(test.fs, test.env, &out)
+
+			argv := append([]string{"program", "completions"}, test.args...)
+			r := app.Parse(argv)
+			if r.Action != charli.Proceed {
+				t.Fatalf("unexpected action %v: %v", r.Action, r.Errs)
+			}
+			r.RunCommand()
+
+			if test.wantErr != "" {
+				if len(r.Errs) != 1 || r.Errs[0].Error() != test.wantErr {
+					t.Errorf("got errors %v, want '%s'", r.Errs, test.wantErr)
+				}
+			} else if r.Fail {
+				t.Errorf("unexpected errors: %v", r.Errs)
+			}
+
+			if diff := deep.Equal(test.fs, test.wantFS); diff != nil {
+				t.Error(diff)
+			}
+			if got := out.String(); got != test.wantOut {
+				t.Errorf("got output:\n%s\nwant:\n%s", got, test.wantOut)
+			}
+		})
+	}
+}
+
+func TestCompleteFlag(t *testing.T) {
+	app := testInstallApp(memFS{}, nil, &bytes.Buffer{})
+
+	r := app.Parse([]string{"program", "--_complete", "completions", ""})
+	if r.Action != charli.Complete || r.Fail {
+		t.Errorf("got action %v, errors %v", r.Action, r.Errs)
+	}
+
+	// The arg being completed must be supplied.
+	r = app.Parse([]string{"program", "--_complete"})
+	if r.Action == charli.Complete {
+		t.Error("got Complete action without an arg to complete")
+	}
+
+	noFlag := charli.App{Commands: []charli.Command{{Name: "other"}}}
+	r = noFlag.Parse([]string{"program", "--_complete", ""})
+	if r.Action == charli.Complete {
+		t.Error("got Complete action without CompleteFlag set")
+	}
+}
+
+func TestCompletionsCommandWithoutFlag(t *testing.T) {
+	app := testInstallApp(memFS{}, nil, &bytes.Buffer{})
+	app.CompleteFlag = ""
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic")
+		}
+	}()
+	// Any parse panics, rather than only running the command.
+	app.Parse([]string{"program", "other"})
+}
@@ -0,0 +1,373 @@
+package charli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// JSONVersion is the version of the schema written by [App.JSON].
+//
+// It's only incremented when the schema changes incompatibly.
+// Fields may be added without incrementing it, so readers should ignore
+// fields they don't recognize.
+const JSONVersion = 1
+
+// These mirror the config structs, for [App.JSON] and [LoadJSON].
+
+type jsonApp struct {
+	Version           int              `json:"version"`
+	Headline          string           `json:"headline,omitempty"`
+	Description       string           `json:"description,omitempty"`
+	Commands          []jsonCommand    `json:"commands,omitempty"`
+	GlobalOptions     []jsonOption     `json:"globalOptions,omitempty"`
+	GlobalConstraints []jsonConstraint `json:"globalConstraints,omitempty"`
+	DefaultCommand    string           `json:"defaultCommand,omitempty"`
+	HelpAccess        []string         `json:"helpAccess"`
+	CompleteFlag      string           `json:"completeFlag,omitempty"`
+}
+
+type jsonCommand struct {
+	Name        string           `json:"name,omitempty"`
+	Headline    string           `json:"headline,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Options     []jsonOption     `json:"options,omitempty"`
+	Constraints []jsonConstraint `json:"constraints,omitempty"`
+	Args        jsonArgs         `json:"args"`
+	Commands    []jsonCommand    `json:"commands,omitempty"`
+}
+
+type jsonOption struct {
+	Short      string    `json:"short,omitempty"`
+	Long       string    `json:"long,omitempty"`
+	Flag       bool      `json:"flag,omitempty"`
+	Repeatable bool      `json:"repeatable,omitempty"`
+	Choices    []string  `json:"choices,omitempty"`
+	Path       *jsonPath `json:"path,omitempty"`
+	Metavar    string    `json:"metavar,omitempty"`
+	Required   bool      `json:"required,omitempty"`
+	Default    string    `json:"default,omitempty"`
+	Env        string    `json:"env,omitempty"`
+	Headline   string    `json:"headline,omitempty"`
+}
+
+type jsonArgs struct {
+	Count    int        `json:"count"`
+	Varadic  bool       `json:"varadic,omitempty"`
+	Metavars []string   `json:"metavars,omitempty"`
+	Choices  [][]string `json:"choices,omitempty"`
+	Paths    []jsonPath `json:"paths,omitempty"`
+}
+
+type jsonPath struct {
+	Kind       string   `json:"kind,omitempty"`
+	Extensions []string `json:"extensions,omitempty"`
+}
+
+type jsonConstraint struct {
+	Kind    string   `json:"kind"`
+	Options []string `json:"options"`
+}
+
+var pathKindNames = map[PathKind]string{
+	NotPath:  "",
+	FilePath: "file",
+	DirPath:  "dir",
+}
+
+var constraintKindNames = map[ConstraintKind]string{
+	AtMostOne:  "at-most-one",
+	ExactlyOne: "exactly-one",
+	AllOrNone:  "all-or-none",
+	Requires:   "requires",
+}
+
+// kindByName returns the key in names with the value name.
+func kindByName[K comparable](names map[K]string, name string) (K, bool) {
+	for k, v := range names {
+		if v == name {
+			return k, true
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+// JSON writes a description of the app to w, as JSON.
+//
+// It's intended for tooling, like documentation sites, linters and wrappers
+// written in other languages. The description contains the app's commands
+// (including subcommands), options, constraints and args, along with its
+// help access mode and default command. It's versioned by [JSONVersion].
+//
+// Fields are named after the config structs' fields, in camelCase
+// (like `defaultCommand`), and fields with zero values are omitted. Kinds are
+// written as strings:
+//   - helpAccess: a list of `flag` and/or `command`
+//   - Path kinds: `file` or `dir`
+//   - Constraint kinds: `at-most-one`, `exactly-one`, `all-or-none` or
+//     `requires`
+//
+// Funcs (like [Option.Validate]) and help formatting settings
+// (like [App.HelpWidth]) aren't included.
+//
+// [LoadJSON] reads the description back into an [App].
+func (app *App) JSON(w io.Writer) error {
+	j := jsonApp{
+		Version:           JSONVersion,
+		Headline:          app.Headline,
+		Description:       app.Description,
+		Commands:          commandsToJSON(app.Commands),
+		GlobalOptions:     optionsToJSON(app.GlobalOptions),
+		GlobalConstraints: constraintsToJSON(app.GlobalConstraints),
+		DefaultCommand:    app.DefaultCommand,
+		HelpAccess:        []string{},
+		CompleteFlag:      app.CompleteFlag,
+	}
+	if app.hasHelpFlags() {
+		j.HelpAccess = append(j.HelpAccess, "flag")
+	}
+	if app.hasHelpCommand() {
+		j.HelpAccess = append(j.HelpAccess, "command")
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(j)
+}
+
+func commandsToJSON(cmds []Command) []jsonCommand {
+	if len(cmds) == 0 {
+		return nil
+	}
+	j := make([]jsonCommand, len(cmds))
+	for i, cmd := range cmds {
+		j[i] = jsonCommand{
+			Name:        cmd.Name,
+			Headline:    cmd.Headline,
+			Description: cmd.Description,
+			Options:     optionsToJSON(cmd.Options),
+			Constraints: constraintsToJSON(cmd.Constraints),
+			Args: jsonArgs{
+				Count:    cmd.Args.Count,
+				Varadic:  cmd.Args.Varadic,
+				Metavars: cmd.Args.Metavars,
+				Choices:  cmd.Args.Choices,
+			},
+			Commands: commandsToJSON(cmd.Commands),
+		}
+		for _, p := range cmd.Args.Paths {
+			j[i].Args.Paths = append(j[i].Args.Paths, pathToJSON(p))
+		}
+	}
+	return j
+}
+
+func optionsToJSON(options []Option) []jsonOption {
+	if len(options) == 0 {
+		return nil
+	}
+	j := make([]jsonOption, len(options))
+	for i, option := range options {
+		j[i] = jsonOption{
+			Long:       option.Long,
+			Flag:       option.Flag,
+			Repeatable: option.Repeatable,
+			Choices:    option.Choices,
+			Metavar:    option.Metavar,
+			Required:   option.Required,
+			Default:    option.Default,
+			Env:        option.Env,
+			Headline:   option.Headline,
+		}
+		if option.Short != 0 {
+			j[i].Short = string(option.Short)
+		}
+		if option.Path.Kind != NotPath {
+			p := pathToJSON(option.Path)
+			j[i].Path = &p
+		}
+	}
+	return j
+}
+
+func constraintsToJSON(constraints []Constraint) []jsonConstraint {
+	if len(constraints) == 0 {
+		return nil
+	}
+	j := make([]jsonConstraint, len(constraints))
+	for i, c := range constraints {
+		j[i] = jsonConstraint{
+			Kind:    constraintKindNames[c.Kind],
+			Options: c.Options,
+		}
+	}
+	return j
+}
+
+func pathToJSON(p Path) jsonPath {
+	return jsonPath{
+		Kind:       pathKindNames[p.Kind],
+		Extensions: p.Extensions,
+	}
+}
+
+// LoadJSON reads an [App] from a description written by [App.JSON].
+//
+// This allows an app to be configured without Go,
+// like from a script which then calls the Go program to parse its args.
+// Funcs (like [Option.Validate]) can't be loaded, so they're left nil.
+// Set them afterwards if they're needed.
+//
+// An error is returned if the JSON is invalid, if its version is newer than
+// [JSONVersion], or if it contains an unknown kind (or a short option that
+// isn't a single character). The app is otherwise loaded as-is: as usual,
+// [App.Parse] panics if it's misconfigured.
+func LoadJSON(r io.Reader) (App, error) {
+	var j jsonApp
+	if err := json.NewDecoder(r).Decode(&j); err != nil {
+		return App{}, err
+	}
+
+	if j.Version < 1 || j.Version > JSONVersion {
+		return App{}, fmt.Errorf("unsupported JSON version %d", j.Version)
+	}
+
+	app := App{
+		Headline:       j.Headline,
+		Description:    j.Description,
+		DefaultCommand: j.DefaultCommand,
+		CompleteFlag:   j.CompleteFlag,
+	}
+
+	var err error
+	if app.Commands, err = commandsFromJSON(j.Commands); err != nil {
+		return App{}, err
+	}
+	if app.GlobalOptions, err = optionsFromJSON(j.GlobalOptions); err != nil {
+		return App{}, err
+	}
+	app.GlobalConstraints, err = constraintsFromJSON(j.GlobalConstraints)
+	if err != nil {
+		return App{}, err
+	}
+
+	for _, name := range j.HelpAccess {
+		switch name {
+		case "flag":
+			app.HelpAccess |= HelpFlag
+		case "command":
+			app.HelpAccess |= HelpCommand
+		default:
+			return App{}, fmt.Errorf("unknown help access '%s'", name)
+		}
+	}
+
+	return app, nil
+}
+
+func commandsFromJSON(j []jsonCommand) ([]Command, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	cmds := make([]Command, len(j))
+	for i, jc := range j {
+		cmd := &cmds[i]
+		*cmd = Command{
+			Name:        jc.Name,
+			Headline:    jc.Headline,
+			Description: jc.Description,
+			Args: Args{
+				Count:    jc.Args.Count,
+				Varadic:  jc.Args.Varadic,
+				Metavars: jc.Args.Metavars,
+				Choices:  jc.Args.Choices,
+			},
+		}
+
+		var err error
+		if cmd.Options, err = optionsFromJSON(jc.Options); err != nil {
+			return nil, err
+		}
+		cmd.Constraints, err = constraintsFromJSON(jc.Constraints)
+		if err != nil {
+			return nil, err
+		}
+		for _, jp := range jc.Args.Paths {
+			p, err := pathFromJSON(jp)
+			if err != nil {
+				return nil, err
+			}
+			cmd.Args.Paths = append(cmd.Args.Paths, p)
+		}
+		if cmd.Commands, err = commandsFromJSON(jc.Commands); err != nil {
+			return nil, err
+		}
+	}
+	return cmds, nil
+}
+
+func optionsFromJSON(j []jsonOption) ([]Option, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	options := make([]Option, len(j))
+	for i, jo := range j {
+		option := &options[i]
+		*option = Option{
+			Long:       jo.Long,
+			Flag:       jo.Flag,
+			Repeatable: jo.Repeatable,
+			Choices:    jo.Choices,
+			Metavar:    jo.Metavar,
+			Required:   jo.Required,
+			Default:    jo.Default,
+			Env:        jo.Env,
+			Headline:   jo.Headline,
+		}
+
+		if jo.Short != "" {
+			r, size := utf8.DecodeRuneInString(jo.Short)
+			if size != len(jo.Short) {
+				return nil, fmt.Errorf(
+					"short option '%s' isn't a single character",
+					jo.Short,
+				)
+			}
+			option.Short = r
+		}
+
+		if jo.Path != nil {
+			p, err := pathFromJSON(*jo.Path)
+			if err != nil {
+				return nil, err
+			}
+			option.Path = p
+		}
+	}
+	return options, nil
+}
+
+func constraintsFromJSON(j []jsonConstraint) ([]Constraint, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	constraints := make([]Constraint, len(j))
+	for i, jc := range j {
+		kind, ok := kindByName(constraintKindNames, jc.Kind)
+		if !ok {
+			return nil, fmt.Errorf("unknown constraint kind '%s'", jc.Kind)
+		}
+		constraints[i] = Constraint{Kind: kind, Options: jc.Options}
+	}
+	return constraints, nil
+}
+
+func pathFromJSON(j jsonPath) (Path, error) {
+	kind, ok := kindByName(pathKindNames, j.Kind)
+	if !ok {
+		return Path{}, fmt.Errorf("unknown path kind '%s'", j.Kind)
+	}
+	return Path{Kind: kind, Extensions: j.Extensions}, nil
+}
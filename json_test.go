@@ -0,0 +1,221 @@
+package charli_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/starriver/charli"
+)
+
+var testJSONApp = charli.App{
+	Headline: "Convert {FILE}s",
+	Commands: []charli.Command{
+		{
+			Name: "convert",
+			Options: []charli.Option{
+				{
+					Short: 'o',
+					Long:  "output",
+					Path:  charli.Path{Kind: charli.DirPath},
+				},
+				{Short: 'q', Flag: true},
+				{Long: "to", Choices: []string{"png", "jpg"}, Required: true},
+			},
+			Constraints: []charli.Constraint{
+				{Kind: charli.AtMostOne, Options: []string{"output", "q"}},
+			},
+			Args: charli.Args{
+				Count:    1,
+				Varadic:  true,
+				Metavars: []string{"FILE"},
+				Paths: []charli.Path{
+					{Kind: charli.FilePath, Extensions: []string{"svg"}},
+				},
+			},
+		},
+		{Name: "version"},
+	},
+	GlobalOptions: []charli.Option{
+		{Short: 'v', Flag: true, Repeatable: true, Env: "VERBOSE"},
+	},
+	DefaultCommand: "convert",
+	HelpAccess:     charli.HelpFlag | charli.HelpCommand,
+	CompleteFlag:   "--_complete",
+}
+
+const testJSONOutput = `
+{
+  "version": 1,
+  "headline": "Convert {FILE}s",
+  "commands": [
+    {
+      "name": "convert",
+      "options": [
+        {
+          "short": "o",
+          "long": "output",
+          "path": {
+            "kind": "dir"
+          }
+        },
+        {
+          "short": "q",
+          "flag": true
+        },
+        {
+          "long": "to",
+          "choices": [
+            "png",
+            "jpg"
+          ],
+          "required": true
+        }
+      ],
+      "constraints": [
+        {
+          "kind": "at-most-one",
+          "options": [
+            "output",
+            "q"
+          ]
+        }
+      ],
+      "args": {
+        "count": 1,
+        "varadic": true,
+        "metavars": [
+          "FILE"
+        ],
+        "paths": [
+          {
+            "kind": "file",
+            "extensions": [
+              "svg"
+            ]
+          }
+        ]
+      }
+    },
+    {
+      "name": "version",
+      "args": {
+        "count": 0
+      }
+    }
+  ],
+  "globalOptions": [
+    {
+      "short": "v",
+      "flag": true,
+      "repeatable": true,
+      "env": "VERBOSE"
+    }
+  ],
+  "defaultCommand": "convert",
+  "helpAccess": [
+    "flag",
+    "command"
+  ],
+  "completeFlag": "--_complete"
+}
+`
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testJSONApp.JSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMain(buf.String(), testJSONOutput[1:], false)
+
+	t.Log(dmp.DiffPrettyText(diffs))
+	for _, diff := range diffs {
+		if diff.Type != diffmatchpatch.DiffEqual {
+			t.Fail()
+		}
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	// The default help access is written explicitly.
+	manApp := testManApp
+	manApp.HelpAccess = charli.HelpFlag
+
+	for _, app := range []charli.App{testJSONApp, manApp} {
+		var buf bytes.Buffer
+		if err := app.JSON(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := charli.LoadJSON(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := deep.Equal(got, app); diff != nil {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestLoadJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			name:  "syntax",
+			input: `{"version": 1,`,
+			err:   "unexpected EOF",
+		},
+		{
+			name:  "missing version",
+			input: `{}`,
+			err:   "unsupported JSON version 0",
+		},
+		{
+			name:  "newer version",
+			input: `{"version": 2}`,
+			err:   "unsupported JSON version 2",
+		},
+		{
+			name:  "help access",
+			input: `{"version": 1, "helpAccess": ["menu"]}`,
+			err:   "unknown help access 'menu'",
+		},
+		{
+			name: "short option",
+			input: `{"version": 1, "commands": [
+				{"options": [{"short": "ab"}]}
+			]}`,
+			err: "short option 'ab' isn't a single character",
+		},
+		{
+			name: "constraint kind",
+			input: `{"version": 1, "globalConstraints": [
+				{"kind": "some", "options": ["a", "b"]}
+			]}`,
+			err: "unknown constraint kind 'some'",
+		},
+		{
+			name: "path kind",
+			input: `{"version": 1, "commands": [
+				{"args": {"count": 1, "paths": [{"kind": "socket"}]}}
+			]}`,
+			err: "unknown path kind 'socket'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := charli.LoadJSON(strings.NewReader(test.input))
+			if err == nil || err.Error() != test.err {
+				t.Errorf("got error %v, want %s", err, test.err)
+			}
+		})
+	}
+}
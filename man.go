@@ -0,0 +1,391 @@
+package charli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// A ManHeader contains the header fields for man pages written by
+// [App.ManPage] and [App.ManPages].
+type ManHeader struct {
+	// Section is the manual section. If blank, it defaults to `1`.
+	Section string
+
+	// Date is the date of the program's last change, like `2024-06-01`.
+	// It's supplied (rather than derived from the current time) so that
+	// pages can be generated reproducibly.
+	Date string
+
+	// Source is the program's source, usually its name and version,
+	// like `tool 1.2.0`.
+	Source string
+
+	// Manual is the title of the manual, like `User Commands`.
+	Manual string
+}
+
+// ManPage writes a man page for cmd to w, formatted with roff.
+//
+// program should be the name of the program.
+// If cmd is nil, the page is for the whole app,
+// named after program (like `tool(1)`).
+// Otherwise, the page is named after cmd's path (like `tool-push(1)`),
+// and cmd should point into the app's command tree, as for [App.Help].
+//
+// The page contains the same information as [App.Help], in these sections:
+//   - NAME: the page's name and headline
+//   - SYNOPSIS: the usage line
+//   - DESCRIPTION
+//   - OPTIONS, including constraints
+//   - COMMANDS: subcommands, if there are any
+//   - SEE ALSO: the pages for parent & subcommands
+//
+// Text surrounded by {curly braces} will be bold, unless it's uppercase
+// (like a metavar), in which case it will be italic.
+func (app *App) ManPage(
+	w io.Writer,
+	program string,
+	cmd *Command,
+	header ManHeader,
+) {
+	print := func(str string) {
+		fmt.Fprint(w, str)
+	}
+	printf := func(format string, a ...any) {
+		fmt.Fprintf(w, format, a...)
+	}
+
+	// A single command is documented on the app's page.
+	if cmd == nil && len(app.Commands) == 1 {
+		cmd = &app.Commands[0]
+	}
+
+	var path []*Command
+	if cmd != nil {
+		path = app.commandPath(cmd)
+	}
+	root, _ := app.rootPath()
+	rootDepth := len(root)
+	name := manPageName(program, path[rootDepth:])
+
+	isGroup := cmd != nil && len(cmd.Commands) != 0
+
+	options := make([]Option, 0, 16)
+	if app.hasHelpFlags() {
+		options = append(options, fakeHelpOption)
+	}
+	var set *optionSet
+	if cmd == nil || !isGroup {
+		set = app.resolveOptions(path)
+		options = append(options, set.options...)
+	}
+
+	section := header.Section
+	if section == "" {
+		section = "1"
+	}
+	printf(
+		".TH %s %s %s %s %s\n",
+		roffQuote(strings.ToUpper(name)),
+		roffQuote(section),
+		roffQuote(header.Date),
+		roffQuote(header.Source),
+		roffQuote(header.Manual),
+	)
+
+	// NAME
+	headline := app.Headline
+	if len(path) > rootDepth || (cmd != nil && cmd.Headline != "") {
+		headline = cmd.Headline
+	}
+	print(".SH NAME\n")
+	print(roffEscape(name, true))
+	if headline != "" {
+		printf(" \\- %s", roffEscape(manPlain(headline), false))
+	}
+	print("\n")
+
+	// SYNOPSIS
+	print(".SH SYNOPSIS\n")
+	print(roffBold(roffEscape(program, true)))
+	for _, word := range app.usage(cmd, path, options, usageStyle{
+		command: func(s string) string { return roffBold(roffEscape(s, true)) },
+		option:  func(s string) string { return roffBold(roffEscape(s, true)) },
+		metavar: func(s string) string { return roffItalic(roffEscape(s, true)) },
+	}) {
+		printf(" %s", word)
+	}
+	print("\n")
+
+	// DESCRIPTION
+	description := app.Description
+	if cmd != nil {
+		description = cmd.Description
+	}
+	if description != "" {
+		print(".SH DESCRIPTION\n")
+		manText(w, trimDescription(description))
+	}
+
+	// OPTIONS
+	if len(options) != 0 {
+		print(".SH OPTIONS\n")
+		for i := range options {
+			option := &options[i]
+
+			var names []string
+			if option.Short != 0 {
+				short := roffEscape("-"+string(option.Short), true)
+				names = append(names, roffBold(short))
+			}
+			if option.Long != "" {
+				long := roffEscape("--"+option.Long, true)
+				names = append(names, roffBold(long))
+			}
+			printf(".TP\n%s", strings.Join(names, ", "))
+			if !option.Flag {
+				metavar := option.Metavar
+				if metavar == "" {
+					metavar = "VALUE"
+				}
+				printf(" %s", roffItalic(roffEscape(metavar, true)))
+			}
+			if option.Repeatable {
+				print("...")
+			}
+			print("\n")
+
+			var right []string
+			if option.Headline != "" {
+				right = append(right, manHighlight(option.Headline))
+			}
+			if option.Required {
+				right = append(right, roffBold("(required)"))
+			}
+			if len(option.Choices) != 0 {
+				choices := make([]string, len(option.Choices))
+				for i, c := range option.Choices {
+					choices[i] = roffBold(roffEscape(c, true))
+				}
+				right = append(right, "["+strings.Join(choices, "|")+"]")
+			}
+			if option.Default != "" {
+				right = append(
+					right,
+					"(default: "+roffBold(roffEscape(option.Default, true))+")",
+				)
+			}
+			if option.Env != "" {
+				right = append(
+					right,
+					"(env: "+roffBold(roffEscape(option.Env, true))+")",
+				)
+			}
+			if len(right) != 0 {
+				print(roffLine(strings.Join(right, " ")))
+			}
+		}
+
+		var constraints []Constraint
+		if set != nil {
+			constraints = set.constraints
+		}
+		for _, c := range constraints {
+			args := make([]string, len(c.Options))
+			for i, name := range c.Options {
+				arg := optionArg(set.names[name])
+				args[i] = roffBold(roffEscape(arg, true))
+			}
+
+			line := describeConstraint(&c, args)
+			print(".PP\n" + roffLine(line))
+		}
+	}
+
+	// COMMANDS
+	var cmds []Command
+	if cmd == nil {
+		cmds = app.Commands
+	} else {
+		cmds = cmd.Commands
+	}
+	if len(cmds) != 0 {
+		print(".SH COMMANDS\n")
+		for _, c := range cmds {
+			printf(".TP\n%s\n", roffBold(roffEscape(c.Name, true)))
+			if c.Headline != "" {
+				print(roffLine(manHighlight(c.Headline)))
+			}
+		}
+	}
+
+	// SEE ALSO
+	var related []string
+	if len(path) > rootDepth {
+		parent := path[rootDepth : len(path)-1]
+		related = append(related, manPageName(program, parent))
+	}
+	for i := range cmds {
+		subpath := append(path[rootDepth:len(path):len(path)], &cmds[i])
+		related = append(related, manPageName(program, subpath))
+	}
+	if len(related) != 0 {
+		print(".SH SEE ALSO\n")
+		for i, r := range related {
+			related[i] = roffBold(roffEscape(r, true)) + "(" + section + ")"
+		}
+		print(roffLine(strings.Join(related, ", ")))
+	}
+}
+
+// ManPages writes man pages for the app and every command, as [App.ManPage]
+// does. For example, an app named `tool` with `push` and `remote add` commands
+// would have pages named `tool.1`, `tool-push.1`, `tool-remote.1` and
+// `tool-remote-add.1`.
+//
+// create is called to open each page by its file name.
+// It could simply call [os.Create].
+// Each page is closed after it's written.
+func (app *App) ManPages(
+	program string,
+	header ManHeader,
+	create func(name string) (io.WriteCloser, error),
+) error {
+	section := header.Section
+	if section == "" {
+		section = "1"
+	}
+
+	write := func(name string, cmd *Command) error {
+		f, err := create(name + "." + section)
+		if err != nil {
+			return err
+		}
+		app.ManPage(f, program, cmd, header)
+		return f.Close()
+	}
+
+	if err := write(program, nil); err != nil {
+		return err
+	}
+
+	// A single command is documented on the app's page, so only its
+	// subcommands get their own.
+	_, cmds := app.rootPath()
+	var walk func(path []*Command, cmds []Command) error
+	walk = func(path []*Command, cmds []Command) error {
+		for i := range cmds {
+			cmd := &cmds[i]
+			subpath := append(path[:len(path):len(path)], cmd)
+			if err := write(manPageName(program, subpath), cmd); err != nil {
+				return err
+			}
+			if err := walk(subpath, cmd.Commands); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(nil, cmds)
+}
+
+// manPageName returns the name of the page for the commands in path,
+// like `tool-remote-add`.
+func manPageName(program string, path []*Command) string {
+	return strings.Join(append([]string{program}, pathNames(path)...), "-")
+}
+
+// manText writes description text as roff paragraphs. See [textBlocks].
+func manText(w io.Writer, text string) {
+	first := true
+	for _, block := range textBlocks(text) {
+		switch block.kind {
+		case paragraphBlock:
+			if !first {
+				fmt.Fprint(w, ".PP\n")
+			}
+			fmt.Fprint(w, roffLine(manHighlight(block.text)))
+		case listItemBlock:
+			fmt.Fprint(w, ".IP \\(bu 2\n")
+			fmt.Fprint(w, roffLine(manHighlight(block.text[2:])))
+		case codeBlock:
+			fmt.Fprint(w, ".PP\n.nf\n")
+			for _, line := range strings.Split(block.text, "\n") {
+				fmt.Fprint(w, roffLine(roffEscape(line, true)))
+			}
+			fmt.Fprint(w, ".fi\n")
+		case blankBlock:
+			continue
+		}
+		first = false
+	}
+}
+
+// manHighlight escapes text for roff, making text in {curly braces} bold
+// or italic.
+func manHighlight(text string) string {
+	return convertHighlights(
+		text,
+		func(s string) string { return roffEscape(s, false) },
+		func(s string) string {
+			if isMetavar(s) {
+				return roffItalic(roffEscape(s, true))
+			}
+			return roffBold(roffEscape(s, true))
+		},
+	)
+}
+
+// manPlain removes {curly brace} highlighting from text.
+func manPlain(text string) string {
+	return highlightRe.ReplaceAllStringFunc(text, func(s string) string {
+		return s[1 : len(s)-1]
+	})
+}
+
+// isMetavar returns true if s looks like a metavar, like `FILE`.
+func isMetavar(s string) bool {
+	hasUpper := false
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+		hasUpper = hasUpper || unicode.IsUpper(r)
+	}
+	return hasUpper
+}
+
+// roffEscape escapes s for use in roff text.
+// If literal is true, hyphens are escaped too, so that they're rendered as
+// minus signs (as they should be for options and commands).
+func roffEscape(s string, literal bool) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	if literal {
+		s = strings.ReplaceAll(s, "-", `\-`)
+	}
+	return s
+}
+
+// roffLine returns s as a line of roff text. Lines starting with a control
+// character are escaped, so that they aren't interpreted as requests.
+func roffLine(s string) string {
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s + "\n"
+}
+
+// roffQuote quotes s as an argument to a roff request.
+func roffQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\(dq`) + `"`
+}
+
+func roffBold(s string) string {
+	return `\fB` + s + `\fR`
+}
+
+func roffItalic(s string) string {
+	return `\fI` + s + `\fR`
+}
@@ -0,0 +1,247 @@
+package charli_test
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/starriver/charli"
+)
+
+var testManApp = charli.App{
+	Headline: "Manage {tool} things",
+	Description: `
+Does things with {remotes}.
+
+  $ tool push --force
+- Reads {FILE} if given
+`,
+	Commands: []charli.Command{
+		{
+			Name:     "push",
+			Headline: "Push to a remote",
+			Options: []charli.Option{
+				{
+					Short:    'f',
+					Long:     "force",
+					Flag:     true,
+					Headline: "Overwrite {REMOTE}, like {-f/--force}",
+				},
+				{
+					Long:     "format",
+					Metavar:  "FMT",
+					Required: true,
+					Choices:  []string{"json", "yaml"},
+				},
+			},
+			Args: charli.Args{
+				Count:    1,
+				Metavars: []string{"REMOTE"},
+			},
+			Constraints: []charli.Constraint{
+				{Kind: charli.Requires, Options: []string{"force", "format"}},
+			},
+		},
+		{
+			Name: "remote",
+			Commands: []charli.Command{
+				{Name: "add", Headline: "Add a remote"},
+			},
+		},
+	},
+	GlobalOptions: []charli.Option{
+		{Long: "dir", Default: ".", Env: "TOOL_DIR"},
+	},
+}
+
+var testManHeader = charli.ManHeader{
+	Date:   "2024-06-01",
+	Source: "tool 1.0",
+	Manual: "User Commands",
+}
+
+const testManOutput = `
+.TH "TOOL" "1" "2024-06-01" "tool 1.0" "User Commands"
+.SH NAME
+tool \- Manage tool things
+.SH SYNOPSIS
+\fBtool\fR [\fIOPTIONS\fR] \fICOMMAND\fR [...]
+.SH DESCRIPTION
+Does things with \fBremotes\fR.
+.PP
+.nf
+  $ tool push \-\-force
+.fi
+.IP \(bu 2
+Reads \fIFILE\fR if given
+.SH OPTIONS
+.TP
+\fB\-h\fR, \fB\-\-help\fR
+Show this help
+.TP
+\fB\-\-dir\fR \fIVALUE\fR
+(default: \fB.\fR) (env: \fBTOOL_DIR\fR)
+.SH COMMANDS
+.TP
+\fBpush\fR
+Push to a remote
+.TP
+\fBremote\fR
+.SH SEE ALSO
+\fBtool\-push\fR(1), \fBtool\-remote\fR(1)
+`
+
+const testManPushOutput = `
+.TH "TOOL-PUSH" "1" "2024-06-01" "tool 1.0" "User Commands"
+.SH NAME
+tool\-push \- Push to a remote
+.SH SYNOPSIS
+\fBtool\fR \fBpush\fR [\fIOPTIONS\fR] \fB\-\-format\fR \fIFMT\fR \fIREMOTE\fR
+.SH OPTIONS
+.TP
+\fB\-h\fR, \fB\-\-help\fR
+Show this help
+.TP
+\fB\-\-dir\fR \fIVALUE\fR
+(default: \fB.\fR) (env: \fBTOOL_DIR\fR)
+.TP
+\fB\-f\fR, \fB\-\-force\fR
+Overwrite \fIREMOTE\fR, like \fB\-f\fR/\fB\-\-force\fR
+.TP
+\fB\-\-format\fR \fIFMT\fR
+\fB(required)\fR [\fBjson\fR|\fByaml\fR]
+.PP
+\fB\-\-force\fR requires: \fB\-\-format\fR
+.SH SEE ALSO
+\fBtool\fR(1)
+`
+
+const testManRemoteAddOutput = `
+.TH "TOOL-REMOTE-ADD" "1" "2024-06-01" "tool 1.0" "User Commands"
+.SH NAME
+tool\-remote\-add \- Add a remote
+.SH SYNOPSIS
+\fBtool\fR \fBremote\fR \fBadd\fR [\fIOPTIONS\fR]
+.SH OPTIONS
+.TP
+\fB\-h\fR, \fB\-\-help\fR
+Show this help
+.TP
+\fB\-\-dir\fR \fIVALUE\fR
+(default: \fB.\fR) (env: \fBTOOL_DIR\fR)
+.SH SEE ALSO
+\fBtool\-remote\fR(1)
+`
+
+// closer is an io.WriteCloser that records whether it was closed.
+type closer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closer) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestManPages(t *testing.T) {
+	pages := map[string]*closer{}
+	var names []string
+	err := testManApp.ManPages(
+		"tool",
+		testManHeader,
+		func(name string) (io.WriteCloser, error) {
+			names = append(names, name)
+			pages[name] = &closer{}
+			return pages[name], nil
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantNames := []string{
+		"tool.1",
+		"tool-push.1",
+		"tool-remote.1",
+		"tool-remote-add.1",
+	}
+	if !slices.Equal(names, wantNames) {
+		t.Fatalf("got pages %v, want %v", names, wantNames)
+	}
+
+	for name, want := range map[string]string{
+		"tool.1":            testManOutput,
+		"tool-push.1":       testManPushOutput,
+		"tool-remote-add.1": testManRemoteAddOutput,
+	} {
+		t.Run(name, func(t *testing.T) {
+			page := pages[name]
+			if !page.closed {
+				t.Error("page wasn't closed")
+			}
+
+			dmp := diffmatchpatch.New()
+			diffs := dmp.DiffMain(page.String(), want[1:], false)
+
+			t.Log(dmp.DiffPrettyText(diffs))
+			for _, diff := range diffs {
+				if diff.Type != diffmatchpatch.DiffEqual {
+					t.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestManPageSingleCommand(t *testing.T) {
+	app := charli.App{
+		Headline: "App headline",
+		Commands: []charli.Command{
+			{
+				Description: "\nThe {only} command.\n",
+				Args:        charli.Args{Varadic: true, Metavars: []string{"FILE"}},
+			},
+		},
+		HelpAccess: charli.HelpCommand,
+	}
+
+	var buf bytes.Buffer
+	app.ManPage(&buf, "cat", nil, charli.ManHeader{Section: "8"})
+	want := `.TH "CAT" "8" "" "" ""
+.SH NAME
+cat \- App headline
+.SH SYNOPSIS
+\fBcat\fR [\fIFILE\fR...]
+.SH DESCRIPTION
+The \fBonly\fR command.
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestManPageShortDescription(t *testing.T) {
+	// The description isn't surrounded by newlines.
+	app := charli.App{
+		Headline:   "App headline",
+		Commands:   []charli.Command{{Description: "Short text"}},
+		HelpAccess: charli.HelpCommand,
+	}
+
+	var buf bytes.Buffer
+	app.ManPage(&buf, "cat", nil, charli.ManHeader{Section: "1"})
+	want := `.TH "CAT" "1" "" "" ""
+.SH NAME
+cat \- App headline
+.SH SYNOPSIS
+\fBcat\fR
+.SH DESCRIPTION
+Short text
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
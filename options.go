@@ -0,0 +1,137 @@
+package charli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// An optionSet contains the options available to a command, resolved from its
+// path. [App.Parse], [App.Help] and [App.Complete] all resolve options this
+// way, so that they agree on which options exist.
+type optionSet struct {
+	// options are the global options, followed by the options of each command
+	// in the path.
+	options []Option
+
+	// names maps long and short option names (without hyphens) to options.
+	// As with [Result.Options], both names map to the same option.
+	names map[string]*Option
+
+	// constraints are the global constraints, followed by the constraints of
+	// each command in the path.
+	constraints []Constraint
+}
+
+// resolveOptions resolves the options available to the last command in path.
+// If path is empty, only the global options are resolved.
+//
+// It panics if any option or constraint is misconfigured.
+func (app *App) resolveOptions(path []*Command) *optionSet {
+	s := &optionSet{
+		options:     app.pathOptions(path),
+		constraints: app.pathConstraints(path),
+	}
+	s.names = make(map[string]*Option, len(s.options)*2)
+
+	for i := range s.options {
+		option := &s.options[i]
+
+		if option.Long != "" {
+			if s.names[option.Long] != nil {
+				panic(
+					fmt.Sprintf("Duplicate option '--%s' configured", option.Long),
+				)
+			}
+			s.names[option.Long] = option
+		}
+		if option.Short != 0 {
+			short := string(option.Short)
+			if s.names[short] != nil {
+				panic(fmt.Sprintf("Duplicate option '-%s' configured", short))
+			}
+			s.names[short] = option
+		}
+
+		checkOption(option)
+	}
+
+	for _, c := range s.constraints {
+		if len(c.Options) < 2 {
+			panic("Constraints must have at least 2 options")
+		}
+		for _, name := range c.Options {
+			if s.names[name] == nil {
+				panic(
+					fmt.Sprintf("Unknown option '%s' in constraint", name),
+				)
+			}
+		}
+	}
+
+	return s
+}
+
+// lookup returns the option named by arg (like `--option` or `-o`),
+// or nil if there isn't one. Any `=value` suffix is ignored.
+//
+// As in [App.Parse], a long arg can name a short option (like `--o`).
+// Combined short options (like `-ab`) aren't looked up.
+func (s *optionSet) lookup(arg string) *Option {
+	var name string
+	if isLongOption(arg) {
+		name, _, _ = strings.Cut(arg[2:], "=")
+	} else if isOption(arg) && utf8.RuneCountInString(arg) == 2 {
+		name = arg[1:]
+	} else {
+		return nil
+	}
+	return s.names[name]
+}
+
+// checkOption panics if option's fields are inconsistent.
+func checkOption(option *Option) {
+	if option.Required && (option.Flag || option.Default != "") {
+		panic(
+			fmt.Sprintf(
+				"Required option '%s' can't be a flag or have a default",
+				optionKey(option),
+			),
+		)
+	}
+
+	if option.Flag && option.Validate != nil {
+		panic(
+			fmt.Sprintf("Validate set on flag '%s'", optionKey(option)),
+		)
+	}
+	if option.Flag && option.Complete != nil {
+		panic(
+			fmt.Sprintf("Complete set on flag '%s'", optionKey(option)),
+		)
+	}
+	if option.Flag && option.Path.Kind != NotPath {
+		panic(
+			fmt.Sprintf("Path set on flag '%s'", optionKey(option)),
+		)
+	}
+
+	if option.Default != "" {
+		if option.Flag {
+			panic(
+				fmt.Sprintf("Default set on flag '%s'", optionKey(option)),
+			)
+		}
+		if len(option.Choices) != 0 &&
+			!slices.Contains(option.Choices, option.Default) {
+			panic(
+				fmt.Sprintf(
+					"Default '%s' for '%s' isn't one of its choices",
+					option.Default,
+					optionKey(option),
+				),
+			)
+		}
+	}
+}
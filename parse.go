@@ -2,6 +2,7 @@ package charli
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -10,23 +11,48 @@ import (
 // (if in doubt, use [os.Args].)
 //
 // See the readme for a complete description of the syntax supported by Parse.
-func (app *App) Parse(argv []string) (r Result) {
+func (app *App) Parse(argv []string) Result {
+	return app.parse(argv, false)
+}
+
+// parse implements [App.Parse].
+//
+// If partial is true, the args are only parsed for completion: no user
+// callbacks are made (validators and [App.LookupEnv]), and required options
+// and constraints aren't checked.
+func (app *App) parse(argv []string, partial bool) (r Result) {
 	program := argv[0]
 	args := argv[1:]
 	nargs := len(args)
 
 	r.App = app
 
-	var cmdMap map[string]*Command
-
 	singleCmd := len(app.Commands) == 1
-	if singleCmd {
-		if app.DefaultCommand != "" {
-			panic("Must have > 1 command when setting DefaultCommand")
+	if singleCmd && app.DefaultCommand != "" {
+		panic("Must have > 1 command when setting DefaultCommand")
+	}
+	checkCommands(app.Commands)
+	if app.CompleteFlag == "" && needsCompleteFlag(app.Commands) {
+		panic("App.CompleteFlag must be set to use CompletionsCommand")
+	}
+
+	// Completion requests bypass parsing altogether. The arg being completed
+	// must follow the flag, even if it's blank.
+	if app.CompleteFlag != "" && nargs > 1 && args[0] == app.CompleteFlag {
+		r.Action = Complete
+		return
+	}
+
+	// path is the chain of commands chosen so far, and cmds are the commands
+	// that may be chosen next.
+	path, cmds := app.rootPath()
+	rootDepth := len(path)
+
+	setPath := func() {
+		if len(path) != 0 {
+			r.Path = path
+			r.Command = path[len(path)-1]
 		}
-		r.Command = &app.Commands[0]
-	} else {
-		cmdMap = app.cmdMap()
 	}
 
 	ha := app.HelpAccess
@@ -34,6 +60,15 @@ func (app *App) Parse(argv []string) (r Result) {
 		ha = HelpFlag
 	}
 
+	isHelpArg := func(arg string) bool {
+		return ((ha&HelpFlag != 0) && (arg == "-h" || arg == "--help")) ||
+			((ha&HelpCommand != 0) && arg == "help")
+	}
+
+	// The help pseudo-command may be supplied before, among or directly after
+	// the leading command names.
+	helpCommandMax := max(len(walkCommands(cmds, args)), 1)
+
 	// Start by scanning for special args: -- and -h/--help/help. This is done
 	// beforehand because (a) we don't want to show any other errors when
 	// requesting help, (b) we need to check for -- with respect to the help
@@ -48,37 +83,42 @@ func (app *App) Parse(argv []string) (r Result) {
 		}
 
 		isHelpFlag := (ha&HelpFlag != 0) && (arg == "-h" || arg == "--help")
-		isHelpCommand := (ha&HelpCommand != 0) && i < 2 && arg == "help"
+		isHelpCommand := (ha&HelpCommand != 0) && i <= helpCommandMax &&
+			arg == "help"
 		if !(isHelpFlag || isHelpCommand) {
 			continue
 		}
 
 		r.Action = Help
 
-		invalidCmd := true
-		if !singleCmd && nargs > 1 {
-			// Check the 1st and 2nd args for a command.
-			for i := range 2 {
-				// We need to disregard 'help' as a command here if necessary:
-				if isOption(args[i]) || ((ha&HelpCommand != 0) && args[i] == "help") {
-					continue
-				}
-				r.Command = cmdMap[args[i]]
-				// If invalid, continue to display help anyway.
-				if r.Command == nil {
-					r.Error(InvalidCommandError{
-						Program: program,
-						Name:    args[i],
-					})
-				} else {
-					invalidCmd = false
-				}
+		// Check the leading args for command names, disregarding the help
+		// args. If any are invalid, continue to display help anyway.
+		for _, arg := range args {
+			if isHelpArg(arg) {
+				continue
+			}
+			if len(cmds) == 0 || isOption(arg) {
+				break
+			}
+
+			cmd := cmdMap(cmds)[arg]
+			if cmd == nil {
+				r.Error(InvalidCommandError{
+					Program:     program,
+					Path:        pathNames(path),
+					Name:        arg,
+					Suggestions: commandSuggestions(arg, cmds, ha),
+				})
+				break
 			}
+			path = append(path, cmd)
+			cmds = cmd.Commands
 		}
+		setPath()
 
 		// Don't error if the user asked for help the "proper" way - with the
 		// help flag, possibly a command, and nothing else.
-		if !(nargs == 1 || (nargs == 2 && !invalidCmd)) {
+		if nargs != 1+len(path)-rootDepth {
 			r.Fail = true
 		}
 
@@ -92,19 +132,17 @@ func (app *App) Parse(argv []string) (r Result) {
 		args = args[:unparsedIndex]
 	}
 
-	var cmdArgs []string
+	cmdArgs := args
 
-	if singleCmd {
-		// r.Command already set.
-		cmdArgs = args
-	} else {
-		// If we don't just have a single command, we now need to select one. If
-		// a default is available, and the first arg doesn't look like a
+	// Unless we just have a single command (without subcommands), we now need
+	// to select one, descending through subcommands as necessary.
+	for len(cmds) != 0 {
+		// If a default is available, and the first arg doesn't look like a
 		// command, use the default.
-		possibleCommand := nargs > 0 && !isOption(args[0])
-		if app.DefaultCommand != "" && !possibleCommand {
-			r.Command = cmdMap[app.DefaultCommand]
-			if r.Command == nil {
+		possibleCommand := len(cmdArgs) > 0 && !isOption(cmdArgs[0])
+		if len(path) == 0 && app.DefaultCommand != "" && !possibleCommand {
+			cmd := cmdMap(cmds)[app.DefaultCommand]
+			if cmd == nil {
 				panic(
 					fmt.Sprintf(
 						"Unknown default command '%s' configured",
@@ -112,71 +150,142 @@ func (app *App) Parse(argv []string) (r Result) {
 					),
 				)
 			}
-			cmdArgs = args
-		} else {
-			if nargs == 0 { // Implicit: app.DefaultCommand can't be set here.
-				// Display help if no command or default.
-				r.Action = Help
-				r.Fail = true
-				return
-			}
-			if !possibleCommand {
-				// The user might've supplied flags - but no command.
-				r.Error(MissingCommandError{
-					Program:    program,
-					HelpAccess: ha,
-				})
-				r.Action = Fatal
-				return
-			}
+			path = append(path, cmd)
+			cmds = cmd.Commands
+			continue
+		}
 
-			r.Command = cmdMap[args[0]]
-			if r.Command == nil {
-				r.Error(InvalidCommandError{
-					Program:     program,
-					Name:        args[0],
-					SuggestHelp: ha,
-				})
-				r.Action = Fatal
-				return
-			}
-			cmdArgs = args[1:]
+		if len(cmdArgs) == 0 {
+			// Display help if no command or default.
+			setPath()
+			r.Action = Help
+			r.Fail = true
+			return
+		}
+		if !possibleCommand {
+			// The user might've supplied flags - but no command.
+			setPath()
+			r.Error(MissingCommandError{
+				Program:    program,
+				Path:       pathNames(path),
+				HelpAccess: ha,
+			})
+			r.Action = Fatal
+			return
+		}
+
+		cmd := cmdMap(cmds)[cmdArgs[0]]
+		if cmd == nil {
+			setPath()
+			r.Error(InvalidCommandError{
+				Program:     program,
+				Path:        pathNames(path),
+				Name:        cmdArgs[0],
+				SuggestHelp: ha,
+				Suggestions: commandSuggestions(cmdArgs[0], cmds, ha),
+			})
+			r.Action = Fatal
+			return
 		}
+		path = append(path, cmd)
+		cmds = cmd.Commands
+		cmdArgs = cmdArgs[1:]
 	}
 
+	setPath()
+
 	// If we've reached this far, we have a valid Command and can begin
 	// parsing the rest of the args within its context.
 
 	// Start by building r.Options. Note the long and short names resolve to the
 	// same struct.
-	mapSizeHint := len(app.GlobalOptions)*2 + len(r.Command.Options)*2
-	r.Options = make(map[string]*OptionResult, mapSizeHint)
-	for _, option := range append(app.GlobalOptions, r.Command.Options...) {
-		o := OptionResult{}
-		o.Option = &option
-		if len(option.Long) != 0 {
-			_, ok := r.Options[option.Long]
-			if ok {
-				panic(fmt.Sprintf("Duplicate option '--%s' configured", option.Long))
-			}
-			r.Options[option.Long] = &o
+	set := app.resolveOptions(path)
+	options := set.options
+	r.Options = make(map[string]*OptionResult, len(set.names))
+	for i := range options {
+		o := &OptionResult{Option: &options[i]}
+		if o.Option.Long != "" {
+			r.Options[o.Option.Long] = o
 		}
-		if option.Short != 0 {
-			s := string(option.Short)
-			_, ok := r.Options[s]
-			if ok {
-				panic(fmt.Sprintf("Duplicate option '-%s' configured", s))
-			}
-			r.Options[s] = &o
+		if o.Option.Short != 0 {
+			r.Options[string(o.Option.Short)] = o
 		}
 	}
 
+	// Options seen on the command line (or in the environment), whether valid
+	// or not, mapped to the arg that supplied them as the user typed it.
+	// Options seen on the command line won't fall back to their environment
+	// variables.
+	seen := make(map[*OptionResult]string, len(options))
+
+	// Options that were supplied with an invalid (or missing) value. These
+	// are excluded from constraint checks, so that errors don't cascade.
+	invalid := make(map[*OptionResult]bool)
+
 	var pairedOption *OptionResult
 	var pairedOptionArg string
 
-	// This is only used twice below, but it feels just a lil too complex to
+	// Validators are run once everything else has been checked, so these are
+	// queued in command-line order.
+	var validations []func()
+	validateOption := func(o *OptionResult, value, joinedArg string) {
+		validate := o.Option.Validate
+		if validate == nil {
+			return
+		}
+		validations = append(validations, func() {
+			if err := validate(value); err != nil {
+				r.Error(ValidationError{
+					Option:    o.Option,
+					JoinedArg: joinedArg,
+					Value:     value,
+					Err:       err,
+				})
+			}
+		})
+	}
+	validateArg := func(i int, value string) {
+		validations = append(validations, func() {
+			// Extraneous args will have been dropped, and invalid choices
+			// already reported.
+			validate := r.Command.Args.validator(i)
+			if i >= len(r.Args) || validate == nil ||
+				!r.Command.Args.validChoice(i, value) {
+				return
+			}
+			if err := validate(value); err != nil {
+				r.Error(ValidationError{
+					JoinedArg: value,
+					Metavar:   r.Command.Args.metavar(i),
+					Value:     value,
+					Err:       err,
+				})
+			}
+		})
+	}
+
+	// Positional args' choices are checked as they're encountered, so that
+	// errors are reported in command-line order.
+	addArg := func(arg string) {
+		i := len(r.Args)
+		rca := &r.Command.Args
+		// Extraneous args are reported separately, below.
+		if (rca.Varadic || i < rca.Count) && !rca.validChoice(i, arg) {
+			r.Error(InvalidChoiceError{
+				JoinedArg: arg,
+				Metavar:   rca.metavar(i),
+				Value:     arg,
+				Choices:   rca.choices(i),
+			})
+		}
+		validateArg(i, arg)
+		r.Args = append(r.Args, arg)
+	}
+
+	// This is used a few times below, and it feels just a lil too complex to
 	// repeat.
-	checkChoice := func(option *Option, value string, joinedArg string) bool {
+	checkChoice := func(o *OptionResult, value string, joinedArg string) bool {
+		option := o.Option
 		if len(option.Choices) == 0 {
 			return true
 		}
@@ -187,6 +296,7 @@ func (app *App) Parse(argv []string) (r Result) {
 			}
 		}
 
+		invalid[o] = true
 		r.Error(InvalidChoiceError{
 			Option:    option,
 			JoinedArg: joinedArg,
@@ -200,12 +310,13 @@ func (app *App) Parse(argv []string) (r Result) {
 		if pairedOption != nil {
 			if !isOption(arg) {
 				combinedArg := fmt.Sprintf("%s %s", pairedOptionArg, arg)
-				ok := checkChoice(pairedOption.Option, arg, combinedArg)
+				ok := checkChoice(pairedOption, arg, combinedArg)
 				if ok {
-					pairedOption.Value = arg
-					pairedOption.IsSet = true
+					pairedOption.set(arg, combinedArg, SourceArgs)
+					validateOption(pairedOption, arg, combinedArg)
 				}
 			} else {
+				invalid[pairedOption] = true
 				r.Error(AmbiguousValueError{
 					Option:    pairedOption.Option,
 					OptionArg: pairedOptionArg,
@@ -253,30 +364,49 @@ func (app *App) Parse(argv []string) (r Result) {
 
 			optionStrs = strings.Split(arg, "")[1:]
 		} else {
-			r.Args = append(r.Args, arg)
+			addArg(arg)
 			continue
 		}
 
 		// Iterate through the option(s) that make up this arg. In most cases,
 		// this'll just be one iteration (because this won't be combined short
 		// args).
+		suggested := false
 		for _, name := range optionStrs {
 			o := r.Options[name]
 			if o == nil {
+				// Only make suggestions once per arg.
+				var suggestions []string
+				if !suggested {
+					optionArg := arg
+					if len(combinedValue) != 0 {
+						optionArg = "--" + name
+					}
+					suggestions = optionSuggestions(optionArg, options, ha)
+					suggested = true
+				}
+
 				if combinedShort {
 					r.Error(InvalidOptionError{
 						Arg:         "-" + name,
 						CombinedArg: arg,
+						Suggestions: suggestions,
 					})
 				} else {
 					r.Error(InvalidOptionError{
-						Arg: arg,
+						Arg:         arg,
+						Suggestions: suggestions,
 					})
 				}
 				continue
 			}
+			if isLongOption(arg) {
+				seen[o] = "--" + name
+			} else {
+				seen[o] = "-" + name
+			}
 
-			if o.IsSet {
+			if o.IsSet && !o.Option.Repeatable {
 				if combinedShort {
 					r.Error(DuplicateOptionError{
 						Option:      o,
@@ -293,8 +423,9 @@ func (app *App) Parse(argv []string) (r Result) {
 			}
 
 			if o.Option.Flag {
-				o.IsSet = true
+				o.set("", seen[o], SourceArgs)
 			} else if combinedShort {
+				invalid[o] = true
 				r.Error(CombinedValueError{
 					Option:      o.Option,
 					Arg:         "-" + name,
@@ -302,10 +433,10 @@ func (app *App) Parse(argv []string) (r Result) {
 				})
 				continue
 			} else if len(combinedValue) != 0 {
-				ok := checkChoice(o.Option, combinedValue, arg)
+				ok := checkChoice(o, combinedValue, arg)
 				if ok {
-					o.Value = combinedValue
-					o.IsSet = true
+					o.set(combinedValue, arg, SourceArgs)
+					validateOption(o, combinedValue, arg)
 				}
 			} else {
 				pairedOption = o
@@ -314,11 +445,16 @@ func (app *App) Parse(argv []string) (r Result) {
 		}
 	}
 
+	for _, arg := range unparsedArgs {
+		addArg(arg)
+	}
+
 	if pairedOption != nil {
 		metavar := pairedOption.Option.Metavar
 		if metavar == "" {
 			metavar = "ARG"
 		}
+		invalid[pairedOption] = true
 		r.Error(MissingValueError{
 			Option:  pairedOption.Option,
 			Arg:     pairedOptionArg,
@@ -326,13 +462,83 @@ func (app *App) Parse(argv []string) (r Result) {
 		})
 	}
 
+	// Fall back to environment variables for any options that weren't on the
+	// command line.
+	if app.LookupEnv != nil && !partial {
+		for _, option := range options {
+			if option.Env == "" {
+				continue
+			}
+
+			o := r.Options[optionKey(&option)]
+			if seen[o] != "" {
+				continue
+			}
+
+			value, ok := app.LookupEnv(option.Env)
+			if !ok || value == "" {
+				continue
+			}
+
+			joinedArg := fmt.Sprintf("%s=%s", option.Env, value)
+			seen[o] = joinedArg
+
+			if option.Flag {
+				set, err := strconv.ParseBool(value)
+				if err != nil {
+					invalid[o] = true
+					r.Error(InvalidEnvFlagError{
+						Option: o.Option,
+						Env:    option.Env,
+						Value:  value,
+					})
+				} else if set {
+					o.set("", joinedArg, SourceEnv)
+				}
+				continue
+			}
+
+			if checkChoice(o, value, joinedArg) {
+				o.set(value, joinedArg, SourceEnv)
+				validateOption(o, value, joinedArg)
+			}
+		}
+	}
+
+	if !partial {
+		// Check that required options were supplied. Don't report options
+		// that were supplied but invalid.
+		for _, option := range options {
+			o := r.Options[optionKey(&option)]
+			if option.Required && !o.IsSet && seen[o] == "" {
+				r.Error(MissingOptionError{
+					Option: o.Option,
+					Arg:    optionArg(&option),
+				})
+			}
+		}
+
+		// Check the constraints between options.
+		for _, c := range set.constraints {
+			r.checkConstraint(&c, seen, invalid)
+		}
+	}
+
+	// Anything still unset gets its default.
+	for _, option := range options {
+		o := r.Options[optionKey(&option)]
+		if !o.IsSet && option.Default != "" {
+			o.Value = option.Default
+			o.Source = SourceDefault
+		}
+	}
+
 	// Every option has now been processed - now we just need to validate the
 	// (non-option) args count.
 
 	// We're about to access this a lot.
 	rca := &r.Command.Args
 
-	r.Args = append(r.Args, unparsedArgs...)
 	n := len(r.Args)
 
 	if !rca.Varadic && n > rca.Count {
@@ -345,10 +551,7 @@ func (app *App) Parse(argv []string) (r Result) {
 	if n < rca.Count {
 		metavars := make([]string, rca.Count-n)
 		for i := range len(metavars) {
-			metavars[i] = "ARG"
-			if n+i < len(rca.Metavars) {
-				metavars[i] = rca.Metavars[n+i]
-			}
+			metavars[i] = rca.metavar(n + i)
 		}
 
 		r.Error(MissingArgsError{
@@ -362,10 +565,123 @@ func (app *App) Parse(argv []string) (r Result) {
 		r.Args = []string{}
 	}
 
+	if !partial {
+		for _, validate := range validations {
+			validate()
+		}
+	}
+
 	r.Action = Proceed
 	return
 }
 
+// checkConstraint reports an error if the options supplied violate c.
+// seen should map each supplied option to its arg as typed by the user.
+//
+// If any of c's options are invalid (as they've already been reported),
+// nothing is checked.
+func (r *Result) checkConstraint(
+	c *Constraint,
+	seen map[*OptionResult]string,
+	invalid map[*OptionResult]bool,
+) {
+	for _, name := range c.Options {
+		if invalid[r.Options[name]] {
+			return
+		}
+	}
+
+	// Split the constrained options by whether they were supplied.
+	var supplied, unsupplied []string
+	for _, name := range c.Options {
+		o := r.Options[name]
+		if o.IsSet {
+			supplied = append(supplied, seen[o])
+		} else {
+			unsupplied = append(unsupplied, optionArg(o.Option))
+		}
+	}
+
+	switch c.Kind {
+	case AtMostOne, ExactlyOne:
+		if len(supplied) > 1 {
+			r.Error(ConflictingOptionsError{
+				Constraint: c,
+				Args:       supplied,
+			})
+		} else if len(supplied) == 0 && c.Kind == ExactlyOne {
+			r.Error(MissingOneOfError{
+				Constraint: c,
+				Args:       unsupplied,
+			})
+		}
+
+	case AllOrNone:
+		if len(supplied) != 0 && len(unsupplied) != 0 {
+			r.Error(DependentOptionError{
+				Constraint: c,
+				Arg:        supplied[0],
+				Missing:    unsupplied,
+			})
+		}
+
+	case Requires:
+		first := r.Options[c.Options[0]]
+		if first.IsSet && len(unsupplied) != 0 {
+			r.Error(DependentOptionError{
+				Constraint: c,
+				Arg:        seen[first],
+				Missing:    unsupplied,
+			})
+		}
+	}
+}
+
+// quoteArgs formats args for error messages, like `'-a', '-b'`.
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = fmt.Sprintf("'%s'", arg)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// walkCommands matches each of args in turn against the names of cmds,
+// descending into each match's subcommands.
+// It stops at the first arg that doesn't match,
+// returning the matched [Command]s.
+func walkCommands(cmds []Command, args []string) (path []*Command) {
+	for _, arg := range args {
+		if len(cmds) == 0 {
+			break
+		}
+		cmd := cmdMap(cmds)[arg]
+		if cmd == nil {
+			break
+		}
+		path = append(path, cmd)
+		cmds = cmd.Commands
+	}
+	return
+}
+
+// optionKey returns the key for option in [Result.Options].
+func optionKey(option *Option) string {
+	if option.Long != "" {
+		return option.Long
+	}
+	return string(option.Short)
+}
+
+// optionArg returns the argument an option is usually referred to by: its long
+// name if it has one, or its short name otherwise.
+func optionArg(option *Option) string {
+	if option.Long != "" {
+		return "--" + option.Long
+	}
+	return "-" + string(option.Short)
+}
+
 func isOption(arg string) bool {
 	// Note that this returns true if this is either a short or long option.
 	return strings.HasPrefix(arg, "-")
@@ -382,61 +698,138 @@ func suggestHelpArg(ha HelpAccess) string {
 	return "--help"
 }
 
+// suggestHelp returns the command line for accessing help for the command at
+// path (which may be empty, for global help).
+func suggestHelp(program string, path []string, ha HelpAccess) string {
+	words := append([]string{program}, path...)
+	words = append(words, suggestHelpArg(ha))
+	return strings.Join(words, " ")
+}
+
 // InvalidCommandError indicates the user has selected a command that doesn't
 // exist.
 type InvalidCommandError struct {
 	Program     string     // the name of the program
+	Path        []string   // the names of any parent commands
 	Name        string     // the name of the invalid command
 	SuggestHelp HelpAccess // how to suggest CLI help is accessed
+	Suggestions []string   // similarly named commands, most similar first
 }
 
 func (err InvalidCommandError) Error() string {
+	var s string
 	if err.SuggestHelp == 0 {
-		return fmt.Sprintf("'%s' isn't a valid command.", err.Name)
+		s = fmt.Sprintf("'%s' isn't a valid command.", err.Name)
+	} else {
+		s = fmt.Sprintf(
+			"'%s' isn't a valid command - try: `%s`",
+			err.Name,
+			suggestHelp(err.Program, err.Path, err.SuggestHelp),
+		)
 	}
 
-	return fmt.Sprintf(
-		"'%s' isn't a valid command - try: `%s %s`",
-		err.Name,
-		err.Program,
-		suggestHelpArg(err.SuggestHelp),
-	)
+	if len(err.Suggestions) != 0 {
+		s += "\n" + didYouMean(err.Suggestions)
+	}
+	return s
 }
 
 // MissingCommandError indicates that the CLI requires the user to supply a
 // command, yet they didn't.
 //
 // This error only occurs when multiple [Command] structs are configured
-// and DefaultCommand is blank.
+// and DefaultCommand is blank,
+// or when a [Command] with subcommands is chosen without one of them.
 type MissingCommandError struct {
 	Program    string     // the name of the program
+	Path       []string   // the names of any parent commands
 	HelpAccess HelpAccess // how to suggest CLI help is accessed
 }
 
 func (err MissingCommandError) Error() string {
 	return fmt.Sprintf(
-		"no command supplied - try: `%s %s`",
-		err.Program,
-		suggestHelpArg(err.HelpAccess),
+		"no command supplied - try: `%s`",
+		suggestHelp(err.Program, err.Path, err.HelpAccess),
 	)
 }
 
 // InvalidChoiceError indicates that the user has supplied an invalid choice
-// as the value for an option which has Choices set.
+// as the value for an option which has Choices set,
+// or for a positional argument with [Args.Choices].
 type InvalidChoiceError struct {
-	Option    *Option // the [Option] in question
+	Option    *Option // the [Option] in question, or nil for positional arguments
 	JoinedArg string  // the argument(s) in question, which may be concatenated
+	Metavar   string  // the metavar, if this is a positional argument
 	Value     string  // the invalid value
+
+	// Choices are the valid choices, if this is a positional argument.
+	// Otherwise, they're the [Option.Choices].
+	Choices []string
 }
 
 func (err InvalidChoiceError) Error() string {
+	choices := err.Choices
+	if choices == nil && err.Option != nil {
+		choices = err.Option.Choices
+	}
+
+	if err.Metavar != "" {
+		return fmt.Sprintf(
+			"invalid %s '%s': must be one of [%s]",
+			err.Metavar,
+			err.JoinedArg,
+			strings.Join(choices, "|"),
+		)
+	}
 	return fmt.Sprintf(
 		"invalid '%s': must be one of [%s]",
 		err.JoinedArg,
-		strings.Join(err.Option.Choices, "|"),
+		strings.Join(choices, "|"),
 	)
 }
 
+// InvalidEnvFlagError indicates that the environment variable for a flag
+// (see [Option.Env]) isn't a boolean.
+type InvalidEnvFlagError struct {
+	Option *Option // the [Option] in question
+	Env    string  // the name of the environment variable
+	Value  string  // the invalid value
+}
+
+func (err InvalidEnvFlagError) Error() string {
+	return fmt.Sprintf(
+		"invalid '%s=%s': must be true or false",
+		err.Env,
+		err.Value,
+	)
+}
+
+// ValidationError indicates that an option or positional argument's value
+// was rejected by its [Option.Validate] or [Args.Validators] func.
+type ValidationError struct {
+	Option    *Option // the [Option] in question, or nil for positional arguments
+	JoinedArg string  // the argument(s) in question, which may be concatenated
+	Metavar   string  // the metavar, if this is a positional argument
+	Value     string  // the invalid value
+	Err       error   // the error returned by the validator
+}
+
+func (err ValidationError) Error() string {
+	if err.Metavar != "" {
+		return fmt.Sprintf(
+			"invalid %s '%s': %v",
+			err.Metavar,
+			err.JoinedArg,
+			err.Err,
+		)
+	}
+	return fmt.Sprintf("invalid '%s': %v", err.JoinedArg, err.Err)
+}
+
+func (err ValidationError) Unwrap() error {
+	return err.Err
+}
+
 // AmbiguousValueError indicates that the user has supplied a value for an
 // option that looks like another option itself
 // (that is, the value starts with '-').
@@ -465,17 +858,28 @@ func (err AmbiguousValueError) Error() string {
 type InvalidOptionError struct {
 	Arg         string // the invalid option's argument
 	CombinedArg string // the combined argument it is part of (if applicable)
+
+	// Suggestions are similarly named options, most similar first,
+	// like `--option`.
+	Suggestions []string
 }
 
 func (err InvalidOptionError) Error() string {
+	var s string
 	if len(err.CombinedArg) != 0 {
-		return fmt.Sprintf(
+		s = fmt.Sprintf(
 			"unrecognized option '%s' in '%s'",
 			err.Arg,
 			err.CombinedArg,
 		)
+	} else {
+		s = fmt.Sprintf("unrecognized option: '%s'", err.Arg)
+	}
+
+	if len(err.Suggestions) != 0 {
+		s += "\n" + didYouMean(err.Suggestions)
 	}
-	return fmt.Sprintf("unrecognized option: '%s'", err.Arg)
+	return s
 }
 
 // CombinedEqualsError indicates that the user attempted to use '=' in a
@@ -493,6 +897,8 @@ func (err CombinedEqualsError) Error() string {
 
 // DuplicateOptionError indicates that the user supplied the same option more
 // than once.
+//
+// This error doesn't occur for [Option.Repeatable] options.
 type DuplicateOptionError struct {
 	Option      *OptionResult // the [OptionResult] set in the first instance
 	Arg         string        // the argument in question
@@ -542,6 +948,56 @@ func (err MissingValueError) Error() string {
 	return fmt.Sprintf("missing value %s for '%s'", err.Metavar, err.Arg)
 }
 
+// MissingOptionError indicates that the user didn't supply an option with
+// [Option.Required] set.
+type MissingOptionError struct {
+	Option *Option // the [Option] in question
+	Arg    string  // the option's name, like `--option` (or `-o` if short only)
+}
+
+func (err MissingOptionError) Error() string {
+	return fmt.Sprintf("missing required option: '%s'", err.Arg)
+}
+
+// ConflictingOptionsError indicates that the user supplied more than one of
+// a set of options constrained by [AtMostOne] or [ExactlyOne].
+type ConflictingOptionsError struct {
+	Constraint *Constraint // the [Constraint] in question
+	Args       []string    // the conflicting arguments
+}
+
+func (err ConflictingOptionsError) Error() string {
+	return fmt.Sprintf("conflicting options: %s", quoteArgs(err.Args))
+}
+
+// MissingOneOfError indicates that the user didn't supply any of a set of
+// options constrained by [ExactlyOne].
+type MissingOneOfError struct {
+	Constraint *Constraint // the [Constraint] in question
+	Args       []string    // the names of the options, like `--option`
+}
+
+func (err MissingOneOfError) Error() string {
+	return fmt.Sprintf("missing one of: %s", quoteArgs(err.Args))
+}
+
+// DependentOptionError indicates that the user supplied an option without
+// the other options it depends on,
+// as constrained by [AllOrNone] or [Requires].
+type DependentOptionError struct {
+	Constraint *Constraint // the [Constraint] in question
+	Arg        string      // the argument that was supplied
+	Missing    []string    // the names of the missing options, like `--option`
+}
+
+func (err DependentOptionError) Error() string {
+	return fmt.Sprintf(
+		"'%s' must be used with: %s",
+		err.Arg,
+		quoteArgs(err.Missing),
+	)
+}
+
 // TooManyArgsError indicates that the user supplied more positional arguments
 // than were allowed by the [Args] Count.
 //
@@ -1,7 +1,10 @@
 package charli_test
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/go-test/deep"
@@ -88,6 +91,93 @@ var testParseTemplate = charli.App{
 				Metavars: []string{"A"},
 			},
 		},
+		{
+			Name: "repeat",
+			Options: []charli.Option{
+				{
+					Short:      'v',
+					Flag:       true,
+					Repeatable: true,
+				},
+				{
+					Short:      'I',
+					Long:       "include",
+					Repeatable: true,
+				},
+				{
+					Long:       "tag",
+					Choices:    []string{"a", "b"},
+					Repeatable: true,
+				},
+			},
+		},
+		{
+			Name: "defaults",
+			Options: []charli.Option{
+				{
+					Long:    "format",
+					Choices: []string{"json", "yaml"},
+					Default: "json",
+				},
+			},
+		},
+		{
+			Name: "required",
+			Options: []charli.Option{
+				{
+					Long:     "token",
+					Required: true,
+				},
+				{
+					Short:    'n',
+					Required: true,
+				},
+			},
+		},
+		{
+			Name: "constraints",
+			Options: []charli.Option{
+				{Short: 'j', Long: "json", Flag: true},
+				{Short: 'y', Long: "yaml", Flag: true},
+				{Long: "cert"},
+				{Long: "key"},
+				{Short: 'u', Long: "user"},
+				{Short: 'p', Long: "pass"},
+			},
+			Constraints: []charli.Constraint{
+				{Kind: charli.ExactlyOne, Options: []string{"json", "yaml"}},
+				{Kind: charli.Requires, Options: []string{"cert", "key"}},
+				{Kind: charli.AllOrNone, Options: []string{"u", "p"}},
+				{Kind: charli.AtMostOne, Options: []string{"g", "cert"}},
+			},
+		},
+		{
+			Name: "remote",
+			Options: []charli.Option{
+				{
+					Long: "dry-run",
+					Flag: true,
+				},
+			},
+			Commands: []charli.Command{
+				{
+					Name: "add",
+					Options: []charli.Option{
+						{
+							Short: 'f',
+							Flag:  true,
+						},
+					},
+					Args: charli.Args{
+						Count:    2,
+						Metavars: []string{"NAME", "URL"},
+					},
+				},
+				{
+					Name: "prune",
+				},
+			},
+		},
 	},
 }
 
@@ -245,7 +335,7 @@ var testParseCases = []struct {
 		output: charli.Result{
 			Action: charli.Proceed,
 			Options: map[string]*charli.OptionResult{
-				"g": {IsSet: true},
+				"g": {IsSet: true, Source: charli.SourceArgs},
 			},
 		},
 		cmdName: "zero",
@@ -340,11 +430,11 @@ var testParseCases = []struct {
 		output: charli.Result{
 			Action: charli.Proceed,
 			Options: map[string]*charli.OptionResult{
-				"long":   {Value: "ok", IsSet: true},
-				"c":      {Value: "a", IsSet: true},
-				"choice": {Value: "a", IsSet: true},
-				"f":      {IsSet: true},
-				"flag":   {IsSet: true},
+				"long":   {Value: "ok", IsSet: true, Source: charli.SourceArgs},
+				"c":      {Value: "a", IsSet: true, Source: charli.SourceArgs},
+				"choice": {Value: "a", IsSet: true, Source: charli.SourceArgs},
+				"f":      {IsSet: true, Source: charli.SourceArgs},
+				"flag":   {IsSet: true, Source: charli.SourceArgs},
 				"g":      {},
 			},
 		},
@@ -356,8 +446,8 @@ var testParseCases = []struct {
 			Action: charli.Proceed,
 			Options: map[string]*charli.OptionResult{
 				"long":   {},
-				"c":      {Value: "a", IsSet: true},
-				"choice": {Value: "a", IsSet: true},
+				"c":      {Value: "a", IsSet: true, Source: charli.SourceArgs},
+				"choice": {Value: "a", IsSet: true, Source: charli.SourceArgs},
 				"f":      {},
 				"flag":   {},
 				"g":      {},
@@ -374,8 +464,8 @@ var testParseCases = []struct {
 				"long":   {},
 				"c":      {},
 				"choice": {},
-				"f":      {IsSet: true},
-				"flag":   {IsSet: true},
+				"f":      {IsSet: true, Source: charli.SourceArgs},
+				"flag":   {IsSet: true, Source: charli.SourceArgs},
 				"g":      {},
 			},
 		},
@@ -425,8 +515,8 @@ var testParseCases = []struct {
 				"long":   {},
 				"c":      {},
 				"choice": {},
-				"f":      {IsSet: true},
-				"flag":   {IsSet: true},
+				"f":      {IsSet: true, Source: charli.SourceArgs},
+				"flag":   {IsSet: true, Source: charli.SourceArgs},
 				"g":      {},
 			},
 		},
@@ -458,8 +548,8 @@ var testParseCases = []struct {
 		output: charli.Result{
 			Action: charli.Proceed,
 			Options: map[string]*charli.OptionResult{
-				"a": {IsSet: true},
-				"b": {IsSet: true},
+				"a": {IsSet: true, Source: charli.SourceArgs},
+				"b": {IsSet: true, Source: charli.SourceArgs},
 				"c": {},
 				"v": {},
 				"g": {},
@@ -472,9 +562,9 @@ var testParseCases = []struct {
 		output: charli.Result{
 			Action: charli.Proceed,
 			Options: map[string]*charli.OptionResult{
-				"a": {IsSet: true},
-				"b": {IsSet: true},
-				"c": {IsSet: true},
+				"a": {IsSet: true, Source: charli.SourceArgs},
+				"b": {IsSet: true, Source: charli.SourceArgs},
+				"c": {IsSet: true, Source: charli.SourceArgs},
 				"v": {},
 				"g": {},
 			},
@@ -487,8 +577,8 @@ var testParseCases = []struct {
 		output: charli.Result{
 			Action: charli.Proceed,
 			Options: map[string]*charli.OptionResult{
-				"a": {IsSet: true},
-				"b": {IsSet: true},
+				"a": {IsSet: true, Source: charli.SourceArgs},
+				"b": {IsSet: true, Source: charli.SourceArgs},
 				"c": {},
 				"v": {},
 				"g": {},
@@ -502,8 +592,8 @@ var testParseCases = []struct {
 		output: charli.Result{
 			Action: charli.Proceed,
 			Options: map[string]*charli.OptionResult{
-				"a": {IsSet: true},
-				"b": {IsSet: true},
+				"a": {IsSet: true, Source: charli.SourceArgs},
+				"b": {IsSet: true, Source: charli.SourceArgs},
 				"c": {},
 				"v": {},
 				"g": {},
@@ -517,8 +607,8 @@ var testParseCases = []struct {
 		output: charli.Result{
 			Action: charli.Proceed,
 			Options: map[string]*charli.OptionResult{
-				"a": {IsSet: true},
-				"b": {IsSet: true},
+				"a": {IsSet: true, Source: charli.SourceArgs},
+				"b": {IsSet: true, Source: charli.SourceArgs},
 				"c": {},
 				"v": {},
 				"g": {},
@@ -601,7 +691,7 @@ var testParseCases = []struct {
 		output: charli.Result{
 			Action: charli.Proceed,
 			Options: map[string]*charli.OptionResult{
-				"opt": {IsSet: true},
+				"opt": {IsSet: true, Source: charli.SourceArgs},
 				"g":   {},
 			},
 			Args: []string{"a", "b", "c"},
@@ -664,6 +754,288 @@ var testParseCases = []struct {
 		},
 		cmdName: "args0v",
 	},
+	{
+		input: []string{"repeat"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"v":       {},
+				"I":       {},
+				"include": {},
+				"tag":     {},
+				"g":       {},
+			},
+		},
+		cmdName: "repeat",
+	},
+	{
+		input: []string{"repeat", "-vv", "-I", "a", "--include=b", "-gv"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"v": {IsSet: true, Source: charli.SourceArgs, Count: 3},
+				"I": {
+					Value:  "b",
+					IsSet:  true,
+					Source: charli.SourceArgs,
+					Values: []string{"a", "b"},
+					Count:  2,
+				},
+				"include": {
+					Value:  "b",
+					IsSet:  true,
+					Source: charli.SourceArgs,
+					Values: []string{"a", "b"},
+					Count:  2,
+				},
+				"tag": {},
+				"g":   {IsSet: true, Source: charli.SourceArgs},
+			},
+		},
+		cmdName: "repeat",
+	},
+	{
+		input: []string{"repeat", "--tag", "a", "--tag", "c", "--tag=b", "-gg"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"v":       {},
+				"I":       {},
+				"include": {},
+				"tag": {
+					Value:  "b",
+					IsSet:  true,
+					Source: charli.SourceArgs,
+					Values: []string{"a", "b"},
+					Count:  2,
+				},
+				"g": {IsSet: true, Source: charli.SourceArgs},
+			},
+		},
+		cmdName: "repeat",
+		errs: []string{
+			"invalid '--tag c': must be one of [a|b]",
+			"duplicate option '-g' in '-gg'",
+		},
+	},
+	{
+		input: []string{"defaults"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"format": {Value: "json", Source: charli.SourceDefault},
+				"g":      {},
+			},
+		},
+		cmdName: "defaults",
+	},
+	{
+		input: []string{"defaults", "--format", "yaml"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"format": {Value: "yaml", IsSet: true, Source: charli.SourceArgs},
+				"g":      {},
+			},
+		},
+		cmdName: "defaults",
+	},
+	{
+		input: []string{"required"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"token": {},
+				"n":     {},
+				"g":     {},
+			},
+		},
+		cmdName: "required",
+		errs: []string{
+			"missing required option: '--token'",
+			"missing required option: '-n'",
+		},
+	},
+	{
+		input: []string{"required", "-n", "1", "--token"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"token": {},
+				"n":     {Value: "1", IsSet: true, Source: charli.SourceArgs},
+				"g":     {},
+			},
+		},
+		cmdName: "required",
+		errs:    []string{"missing value ARG for '--token'"},
+	},
+	{
+		input: []string{"constraints", "-jy", "--cert=c", "-p", "x", "-g"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"j":    {IsSet: true, Source: charli.SourceArgs},
+				"json": {IsSet: true, Source: charli.SourceArgs},
+				"y":    {IsSet: true, Source: charli.SourceArgs},
+				"yaml": {IsSet: true, Source: charli.SourceArgs},
+				"cert": {Value: "c", IsSet: true, Source: charli.SourceArgs},
+				"key":  {},
+				"u":    {},
+				"user": {},
+				"p":    {Value: "x", IsSet: true, Source: charli.SourceArgs},
+				"pass": {Value: "x", IsSet: true, Source: charli.SourceArgs},
+				"g":    {IsSet: true, Source: charli.SourceArgs},
+			},
+		},
+		cmdName: "constraints",
+		errs: []string{
+			"conflicting options: '-j', '-y'",
+			"'--cert' must be used with: '--key'",
+			"'-p' must be used with: '--user'",
+			"conflicting options: '-g', '--cert'",
+		},
+	},
+	{
+		input: []string{"constraints", "--cert", "c", "--key", "k"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"j":    {},
+				"json": {},
+				"y":    {},
+				"yaml": {},
+				"cert": {Value: "c", IsSet: true, Source: charli.SourceArgs},
+				"key":  {Value: "k", IsSet: true, Source: charli.SourceArgs},
+				"u":    {},
+				"user": {},
+				"p":    {},
+				"pass": {},
+				"g":    {},
+			},
+		},
+		cmdName: "constraints",
+		errs:    []string{"missing one of: '--json', '--yaml'"},
+	},
+	{
+		// --key was supplied (without a value), so --cert's requirement
+		// isn't reported too.
+		input: []string{"constraints", "-j", "--cert", "c", "--key"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"j":    {IsSet: true, Source: charli.SourceArgs},
+				"json": {IsSet: true, Source: charli.SourceArgs},
+				"y":    {},
+				"yaml": {},
+				"cert": {Value: "c", IsSet: true, Source: charli.SourceArgs},
+				"key":  {},
+				"u":    {},
+				"user": {},
+				"p":    {},
+				"pass": {},
+				"g":    {},
+			},
+		},
+		cmdName: "constraints",
+		errs:    []string{"missing value ARG for '--key'"},
+	},
+	{
+		input: []string{"remote", "add", "-f", "origin", "url", "--dry-run"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"dry-run": {IsSet: true, Source: charli.SourceArgs},
+				"f":       {IsSet: true, Source: charli.SourceArgs},
+				"g":       {},
+			},
+			Args: []string{"origin", "url"},
+		},
+		cmdName: "remote add",
+	},
+	{
+		input: []string{"remote", "prune", "-g"},
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"dry-run": {},
+				"g":       {IsSet: true, Source: charli.SourceArgs},
+			},
+		},
+		cmdName: "remote prune",
+	},
+	{
+		// No subcommand
+		input: []string{"remote"},
+		output: charli.Result{
+			Action: charli.Help,
+		},
+		cmdName:   "remote",
+		noErrFail: true,
+	},
+	{
+		input: []string{"remote", "--dry-run"},
+		output: charli.Result{
+			Action: charli.Fatal,
+		},
+		cmdName: "remote",
+		errs:    []string{"no command supplied - try: `program remote --help`"},
+	},
+	{
+		input: []string{"remote", "nope"},
+		output: charli.Result{
+			Action: charli.Fatal,
+		},
+		cmdName: "remote",
+		errs: []string{
+			"'nope' isn't a valid command - try: `program remote --help`",
+		},
+	},
+	{
+		input: []string{"remote", "add", "-h"},
+		output: charli.Result{
+			Action: charli.Help,
+		},
+		cmdName: "remote add",
+	},
+	{
+		input: []string{"-h", "remote", "nope"},
+		output: charli.Result{
+			Action: charli.Help,
+		},
+		cmdName: "remote",
+		errs:    []string{"'nope' isn't a valid command."},
+	},
+	{
+		input:      []string{"help", "remote", "add"},
+		helpAccess: charli.HelpCommand,
+		output: charli.Result{
+			Action: charli.Help,
+		},
+		cmdName: "remote add",
+	},
+	{
+		input:      []string{"remote", "add", "help"},
+		helpAccess: charli.HelpCommand,
+		output: charli.Result{
+			Action: charli.Help,
+		},
+		cmdName: "remote add",
+	},
+	{
+		// 'help' here is a positional arg
+		input:      []string{"remote", "add", "a", "help"},
+		helpAccess: charli.HelpCommand,
+		output: charli.Result{
+			Action: charli.Proceed,
+			Options: map[string]*charli.OptionResult{
+				"dry-run": {},
+				"f":       {},
+				"g":       {},
+			},
+			Args: []string{"a", "help"},
+		},
+		cmdName: "remote add",
+	},
 	{
 		input:     []string{},
 		useSingle: true,
@@ -683,8 +1055,8 @@ var testParseCases = []struct {
 		output: charli.Result{
 			Action: charli.Proceed,
 			Options: map[string]*charli.OptionResult{
-				"f":    {IsSet: true},
-				"flag": {IsSet: true},
+				"f":    {IsSet: true, Source: charli.SourceArgs},
+				"flag": {IsSet: true, Source: charli.SourceArgs},
 			},
 		},
 		cmdName: "only",
@@ -744,11 +1116,18 @@ func TestParse(t *testing.T) {
 			if test.cmdName != "" {
 				if test.useSingle {
 					want.Command = &app.Commands[0]
+					want.Path = []*charli.Command{want.Command}
 				} else {
-					for _, cmd := range app.Commands {
-						if cmd.Name == test.cmdName {
-							want.Command = &cmd
-							break
+					// Nested commands are separated by spaces.
+					cmds := app.Commands
+					for _, name := range strings.Fields(test.cmdName) {
+						for i := range cmds {
+							if cmds[i].Name == name {
+								want.Command = &cmds[i]
+								want.Path = append(want.Path, want.Command)
+								cmds = cmds[i].Commands
+								break
+							}
 						}
 					}
 				}
@@ -771,9 +1150,10 @@ func TestParse(t *testing.T) {
 			}
 
 			// We test whether options are correctly mapped implicitly, so blank
-			// out the pointers.
+			// out the pointers. Arg is tested separately.
 			for _, o := range got.Options {
 				o.Option = nil
+				o.Arg = ""
 			}
 
 			if test.errs == nil {
@@ -863,6 +1243,80 @@ func TestParsePanic(t *testing.T) {
 		DefaultCommand: "cmd1",
 	}
 
+	defaultOnFlag := charli.App{
+		Commands: []charli.Command{
+			{
+				Options: []charli.Option{
+					{
+						Long:    "flag",
+						Flag:    true,
+						Default: "yes",
+					},
+				},
+			},
+		},
+	}
+
+	defaultNotChoice := charli.App{
+		Commands: []charli.Command{
+			{
+				Options: []charli.Option{
+					{
+						Long:    "choice",
+						Choices: []string{"a", "b"},
+						Default: "c",
+					},
+				},
+			},
+		},
+	}
+
+	requiredFlag := charli.App{
+		Commands: []charli.Command{
+			{
+				Options: []charli.Option{
+					{
+						Long:     "flag",
+						Flag:     true,
+						Required: true,
+					},
+				},
+			},
+		},
+	}
+
+	validateFlag := charli.App{
+		Commands: []charli.Command{
+			{
+				Options: []charli.Option{
+					{
+						Long:     "flag",
+						Flag:     true,
+						Validate: func(string) error { return nil },
+					},
+				},
+			},
+		},
+	}
+
+	unknownConstraintOption := charli.App{
+		Commands: []charli.Command{
+			{
+				Options: []charli.Option{
+					{
+						Long: "a",
+					},
+				},
+				Constraints: []charli.Constraint{
+					{
+						Kind:    charli.AtMostOne,
+						Options: []string{"a", "b"},
+					},
+				},
+			},
+		},
+	}
+
 	expectPanic := func(t *testing.T) {
 		if r := recover(); r == nil {
 			t.Errorf("expected panic")
@@ -893,6 +1347,31 @@ func TestParsePanic(t *testing.T) {
 		defer expectPanic(t)
 		defaultWithSingleCmd.Parse([]string{"program"})
 	})
+
+	t.Run("default on flag", func(t *testing.T) {
+		defer expectPanic(t)
+		defaultOnFlag.Parse([]string{"program"})
+	})
+
+	t.Run("default not in choices", func(t *testing.T) {
+		defer expectPanic(t)
+		defaultNotChoice.Parse([]string{"program"})
+	})
+
+	t.Run("required flag", func(t *testing.T) {
+		defer expectPanic(t)
+		requiredFlag.Parse([]string{"program"})
+	})
+
+	t.Run("validate on flag", func(t *testing.T) {
+		defer expectPanic(t)
+		validateFlag.Parse([]string{"program"})
+	})
+
+	t.Run("unknown option in constraint", func(t *testing.T) {
+		defer expectPanic(t)
+		unknownConstraintOption.Parse([]string{"program"})
+	})
 }
 
 // Special case: ErrorHandler provided
@@ -916,3 +1395,355 @@ func TestErrorHandler(t *testing.T) {
 		t.Error("r.Errs should be empty")
 	}
 }
+
+// Special case: options falling back to environment variables
+func TestParseEnv(t *testing.T) {
+	env := map[string]string{
+		"TOKEN":   "secret",
+		"FORMAT":  "xml",
+		"VERBOSE": "true",
+		"QUIET":   "nah",
+		"EMPTY":   "",
+	}
+
+	app := charli.App{
+		Commands: []charli.Command{
+			{
+				Options: []charli.Option{
+					{Long: "token", Env: "TOKEN"},
+					{Long: "name", Env: "TOKEN"},
+					{Long: "format", Env: "FORMAT", Choices: []string{"json"}},
+					{Short: 'v', Flag: true, Env: "VERBOSE"},
+					{Short: 'q', Flag: true, Env: "QUIET"},
+					{Long: "empty", Env: "EMPTY"},
+					{Long: "unset", Env: "UNSET"},
+				},
+			},
+		},
+		LookupEnv: func(key string) (string, bool) {
+			v, ok := env[key]
+			return v, ok
+		},
+	}
+
+	r := app.Parse([]string{"program", "--name", "argv"})
+
+	want := map[string]charli.OptionResult{
+		"token":  {Value: "secret", IsSet: true, Source: charli.SourceEnv},
+		"name":   {Value: "argv", IsSet: true, Source: charli.SourceArgs},
+		"format": {},
+		"v":      {IsSet: true, Source: charli.SourceEnv},
+		"q":      {},
+		"empty":  {},
+		"unset":  {},
+	}
+	for name, w := range want {
+		got := *r.Options[name]
+		got.Option = nil
+		got.Arg = ""
+		if diff := deep.Equal(got, w); diff != nil {
+			t.Errorf("%s: %v", name, diff)
+		}
+	}
+
+	gotErrStrings := make([]string, len(r.Errs))
+	for i, err := range r.Errs {
+		gotErrStrings[i] = err.Error()
+	}
+	wantErrStrings := []string{
+		"invalid 'FORMAT=xml': must be one of [json]",
+		"invalid 'QUIET=nah': must be true or false",
+	}
+	if diff := deep.Equal(gotErrStrings, wantErrStrings); diff != nil {
+		t.Error(diff)
+	}
+
+	// Without LookupEnv, nothing should be read.
+	app.LookupEnv = nil
+	r = app.Parse([]string{"program"})
+	if r.Options["token"].IsSet || r.Fail {
+		t.Error("environment shouldn't be read without LookupEnv")
+	}
+}
+
+// Special case: the args that supplied each option
+func TestParseOptionArg(t *testing.T) {
+	app := charli.App{
+		Commands: []charli.Command{
+			{
+				Options: []charli.Option{
+					{Short: 'a', Flag: true},
+					{Short: 'b', Flag: true},
+					{Short: 'p', Long: "paired"},
+					{Long: "joined"},
+					{Long: "env", Env: "ENV"},
+					{Long: "unset"},
+				},
+			},
+		},
+		LookupEnv: func(key string) (string, bool) {
+			return "value", true
+		},
+	}
+
+	r := app.Parse([]string{"program", "-ab", "-p", "1", "--joined=2"})
+
+	want := map[string]string{
+		"a":      "-a",
+		"b":      "-b",
+		"paired": "-p 1",
+		"joined": "--joined=2",
+		"env":    "ENV=value",
+		"unset":  "",
+	}
+	for name, w := range want {
+		if got := r.Options[name].Arg; got != w {
+			t.Errorf("%s: got '%s', want '%s'", name, got, w)
+		}
+	}
+}
+
+func TestParseValidate(t *testing.T) {
+	port := func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 65535 {
+			return errors.New("must be a port number")
+		}
+		return nil
+	}
+	nonEmpty := func(value string) error {
+		if value == "" {
+			return errors.New("must not be empty")
+		}
+		return nil
+	}
+
+	app := charli.App{
+		Commands: []charli.Command{
+			{
+				Options: []charli.Option{
+					{Long: "port", Validate: port},
+					{Short: 't', Repeatable: true, Validate: nonEmpty},
+					{
+						Long:     "format",
+						Choices:  []string{"json"},
+						Validate: nonEmpty,
+					},
+					{Long: "env", Env: "ENV", Validate: port},
+					{Long: "default", Default: "x", Validate: port},
+				},
+				Args: charli.Args{
+					Count:      1,
+					Varadic:    true,
+					Metavars:   []string{"HOST", "PORT"},
+					Validators: []func(string) error{nonEmpty, port},
+				},
+			},
+		},
+		LookupEnv: func(key string) (string, bool) {
+			return "http", true
+		},
+	}
+
+	r := app.Parse([]string{
+		"program",
+		"", "--port", "99999",
+		"-t", "a", "-t", "",
+		"--format=xml",
+		"1", "--", "-1",
+	})
+
+	gotErrStrings := make([]string, len(r.Errs))
+	for i, err := range r.Errs {
+		gotErrStrings[i] = err.Error()
+	}
+	wantErrStrings := []string{
+		// Syntax errors come first.
+		"invalid '--format=xml': must be one of [json]",
+		"invalid HOST '': must not be empty",
+		"invalid '--port 99999': must be a port number",
+		"invalid '-t ': must not be empty",
+		"invalid PORT '-1': must be a port number",
+		"invalid 'ENV=http': must be a port number",
+	}
+	if diff := deep.Equal(gotErrStrings, wantErrStrings); diff != nil {
+		t.Error(diff)
+	}
+
+	var ve charli.ValidationError
+	if !errors.As(r.Errs[2], &ve) || ve.Option.Long != "port" {
+		t.Error("expected a ValidationError for --port")
+	}
+	if r.Options["port"].Value != "99999" {
+		t.Error("invalid value should still be set")
+	}
+}
+
+func TestParseSuggestions(t *testing.T) {
+	app := charli.App{
+		GlobalOptions: []charli.Option{
+			{Long: "color"},
+		},
+		Commands: []charli.Command{
+			{
+				Name: "push",
+				Options: []charli.Option{
+					{Short: 'v', Long: "verbose", Flag: true},
+					{Short: 'f', Long: "force", Flag: true},
+					{Short: 'F', Long: "format"},
+				},
+			},
+			{Name: "pull"},
+			{
+				Name: "remote",
+				Commands: []charli.Command{
+					{Name: "add"},
+				},
+			},
+		},
+		HelpAccess: charli.HelpFlag | charli.HelpCommand,
+	}
+
+	cases := []struct {
+		args []string
+		want []string
+	}{
+		{
+			[]string{"psuh"},
+			[]string{
+				"'psuh' isn't a valid command - try: `program --help`\n" +
+					"did you mean 'push'?",
+			},
+		},
+		{
+			[]string{"pusl"},
+			[]string{
+				"'pusl' isn't a valid command - try: `program --help`\n" +
+					"did you mean 'push' or 'pull'?",
+			},
+		},
+		{
+			[]string{"hlep"},
+			[]string{
+				"'hlep' isn't a valid command - try: `program --help`\n" +
+					"did you mean 'help'?",
+			},
+		},
+		{
+			[]string{"remote", "ad"},
+			[]string{
+				"'ad' isn't a valid command - try: `program remote --help`\n" +
+					"did you mean 'add'?",
+			},
+		},
+		{
+			[]string{"xyzzy"},
+			[]string{"'xyzzy' isn't a valid command - try: `program --help`"},
+		},
+		{
+			[]string{"push", "--verbsoe", "--colour=x", "--hepl", "--forc", "-V"},
+			[]string{
+				"unrecognized option: '--verbsoe'\ndid you mean '--verbose'?",
+				"unrecognized option: '--colour=x'\ndid you mean '--color'?",
+				"unrecognized option: '--hepl'\ndid you mean '--help'?",
+				"unrecognized option: '--forc'\ndid you mean '--force'?",
+				"unrecognized option: '-V'\ndid you mean '-v'?",
+			},
+		},
+		{
+			[]string{"push", "-verbose"},
+			[]string{
+				"unrecognized option '-e' in '-verbose'\n" +
+					"did you mean '--verbose'?",
+				"unrecognized option '-r' in '-verbose'",
+				"unrecognized option '-b' in '-verbose'",
+				"unrecognized option '-o' in '-verbose'",
+				"unrecognized option '-s' in '-verbose'",
+				"unrecognized option '-e' in '-verbose'",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		r := app.Parse(append([]string{"program"}, c.args...))
+
+		got := make([]string, len(r.Errs))
+		for i, err := range r.Errs {
+			got[i] = err.Error()
+		}
+		if diff := deep.Equal(got, c.want); diff != nil {
+			t.Errorf("%v: %v", c.args, diff)
+		}
+	}
+}
+
+func TestParseArgChoices(t *testing.T) {
+	app := charli.App{
+		Commands: []charli.Command{
+			{
+				Args: charli.Args{
+					Count:    1,
+					Varadic:  true,
+					Metavars: []string{"ACTION", "TARGET"},
+					Choices: [][]string{
+						{"start", "stop"},
+						{"api", "web"},
+					},
+					// Validators aren't called for invalid choices.
+					Validators: []func(string) error{
+						func(string) error { return errors.New("unreachable") },
+						nil,
+					},
+				},
+			},
+		},
+	}
+
+	r := app.Parse([]string{"program", "restart", "api", "db", "web"})
+
+	gotErrStrings := make([]string, len(r.Errs))
+	for i, err := range r.Errs {
+		gotErrStrings[i] = err.Error()
+	}
+	wantErrStrings := []string{
+		"invalid ACTION 'restart': must be one of [start|stop]",
+		"invalid TARGET 'db': must be one of [api|web]",
+	}
+	if diff := deep.Equal(gotErrStrings, wantErrStrings); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestParseArgChoicesOrder(t *testing.T) {
+	app := charli.App{
+		Commands: []charli.Command{
+			{
+				Options: []charli.Option{
+					{Long: "format", Choices: []string{"json"}},
+				},
+				Args: charli.Args{
+					Count:    2,
+					Metavars: []string{"ACTION", "TARGET"},
+					Choices:  [][]string{{"start", "stop"}},
+				},
+			},
+		},
+	}
+
+	// Invalid choices are reported in command-line order, among other errors.
+	r := app.Parse([]string{"program", "--format=xml", "restart", "--format"})
+
+	gotErrStrings := make([]string, len(r.Errs))
+	for i, err := range r.Errs {
+		gotErrStrings[i] = err.Error()
+	}
+	wantErrStrings := []string{
+		"invalid '--format=xml': must be one of [json]",
+		"invalid ACTION 'restart': must be one of [start|stop]",
+		"missing value ARG for '--format'",
+		"missing argument: TARGET",
+	}
+	if diff := deep.Equal(gotErrStrings, wantErrStrings); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+
+	"golang.org/x/term"
 )
 
 // A Result is a collection of results returned by [App.Parse].
@@ -37,8 +39,19 @@ type Result struct {
 
 	// Command is the [Command] chosen by the user.
 	// This may be nil when [Result.Action] != [Proceed].
+	//
+	// When subcommands are configured, this is the innermost command chosen.
 	Command *Command
 
+	// Path is the chain of [Command]s leading to [Result.Command],
+	// starting with the top-level command. For example, for
+	// `program remote add`, this would contain the `remote` and `add`
+	// commands.
+	//
+	// If [Result.Command] is set, it is always the last element.
+	// Otherwise, this is nil.
+	Path []*Command
+
 	// Options is a map of [Option] names to [OptionResult]s.
 	//
 	// Both [Option.Short] and [Option.Long] will be set as keys for the
@@ -59,9 +72,10 @@ type Result struct {
 type Action int
 
 const (
-	Proceed Action = iota // proceed to call [Command.Run]
-	Help                  // display help
-	Fatal                 // nothing else to do; [Result.Fail] will always be true
+	Proceed  Action = iota // proceed to call [Command.Run]
+	Help                   // display help
+	Fatal                  // nothing else to do; [Result.Fail] will always be true
+	Complete               // call [Result.PrintCompletions], see [App.CompleteFlag]
 )
 
 // An OptionResult contains parsing results for a single option.
@@ -75,10 +89,62 @@ type OptionResult struct {
 	// was supplied at all.
 	//
 	// If the option is a flag, this will always be blank.
+	// If the option is [Option.Repeatable], this is the last value supplied.
+	//
+	// If the option wasn't supplied, this will be its [Option.Default].
 	Value string
 
 	// IsSet indicates whether the option was supplied.
 	IsSet bool
+
+	// Source indicates where the option was supplied from.
+	Source Source
+
+	// Arg is the argument(s) that supplied the option, as the user typed
+	// them, like `--opt value`, `--opt=value` or `-f`.
+	// If the option was supplied via [Option.Env], this is like `ENV=value`.
+	//
+	// If the option is [Option.Repeatable], this is for the last occurrence.
+	// If the option wasn't supplied, this is blank.
+	Arg string
+
+	// Values contains every value supplied for an [Option.Repeatable] option,
+	// in command-line order.
+	//
+	// This is nil for flags and for options that aren't repeatable.
+	Values []string
+
+	// Count is the number of times an [Option.Repeatable] option was
+	// supplied.
+	//
+	// This is always 0 for options that aren't repeatable -
+	// use [OptionResult.IsSet] instead.
+	Count int
+}
+
+// Source indicates where an option's value came from.
+type Source int
+
+const (
+	SourceNone    Source = iota // the option wasn't supplied
+	SourceArgs                  // the option was supplied on the command line
+	SourceEnv                   // the option was supplied by its [Option.Env]
+	SourceDefault               // the option wasn't supplied; its [Option.Default] was used
+)
+
+// set records a single occurrence of the option.
+func (o *OptionResult) set(value, arg string, source Source) {
+	o.Value = value
+	o.IsSet = true
+	o.Source = source
+	o.Arg = arg
+
+	if o.Option.Repeatable {
+		o.Count++
+		if !o.Option.Flag {
+			o.Values = append(o.Values, value)
+		}
+	}
 }
 
 // Error reports a pre-made error and sets Fail to true.
@@ -136,6 +202,26 @@ func (r *Result) RunCommand() {
 
 // PrintHelp writes global or command help to stderr, depending on whether the
 // user selected a valid command.
+//
+// If [App.HelpWidth] is 0 and stderr is a terminal,
+// help is wrapped to the terminal's width.
 func (r *Result) PrintHelp() {
-	r.App.Help(os.Stderr, os.Args[0], r.Command)
+	app := r.App
+	if app.HelpWidth == 0 {
+		if width, _, err := term.GetSize(int(os.Stderr.Fd())); err == nil {
+			// The copy shares its command tree, so r.Command remains valid.
+			sized := *app
+			sized.HelpWidth = width
+			app = &sized
+		}
+	}
+	app.Help(os.Stderr, os.Args[0], r.Command)
+}
+
+// PrintCompletions writes completions for the current command line to stdout.
+// This is shorthand for:
+//
+//	r.App.Complete(os.Stdout, os.Args)
+func (r *Result) PrintCompletions() {
+	r.App.Complete(os.Stdout, os.Args)
 }
@@ -0,0 +1,153 @@
+package charli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// suggest returns the candidates that are similar to name, most similar
+// first, for "did you mean" hints.
+//
+// Similarity is case-insensitive edit distance, allowing for transposed
+// characters. A single edit is allowed for names shorter than 6 characters,
+// and 1 edit per 3 characters otherwise.
+func suggest(name string, candidates []string) []string {
+	lower := strings.ToLower(name)
+	limit := max(1, len([]rune(name))/3)
+
+	type match struct {
+		candidate string
+		distance  int
+	}
+	var matches []match
+	for _, c := range candidates {
+		if slices.ContainsFunc(matches, func(m match) bool {
+			return m.candidate == c
+		}) {
+			continue
+		}
+		d := editDistance(lower, strings.ToLower(c))
+		if d <= limit {
+			matches = append(matches, match{c, d})
+		}
+	}
+
+	// Stable, so that equally similar candidates stay in configured order.
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return a.distance - b.distance
+	})
+
+	var suggestions []string
+	for _, m := range matches {
+		suggestions = append(suggestions, m.candidate)
+	}
+	return suggestions
+}
+
+// editDistance returns the optimal string alignment distance between a and b:
+// the number of insertions, deletions, substitutions and transpositions
+// of adjacent characters needed to turn one into the other.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// d[i][j] is the distance between the first i runes of a and the first j
+	// runes of b.
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(
+				d[i-1][j]+1,
+				d[i][j-1]+1,
+				d[i-1][j-1]+cost,
+			)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// commandSuggestions returns the names of cmds similar to name.
+func commandSuggestions(name string, cmds []Command, ha HelpAccess) []string {
+	names := make([]string, 0, len(cmds)+1)
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name)
+	}
+	if ha&HelpCommand != 0 {
+		names = append(names, "help")
+	}
+	return suggest(name, names)
+}
+
+// optionSuggestions returns options similar to the invalid option arg
+// (including hyphens), formatted as args like `--option`.
+// Candidates are taken from options, plus the help flags if enabled.
+//
+// Long options are compared by edit distance, as are combined short options
+// (like `-verbose` for `--verbose`).
+// Short options are only suggested if they differ by case,
+// since every short option is within a single edit of another.
+func optionSuggestions(arg string, options []Option, ha HelpAccess) []string {
+	if ha&HelpFlag != 0 {
+		options = append(slices.Clip(options), fakeHelpOption)
+	}
+
+	var longs []string
+	var shorts []rune
+	for _, option := range options {
+		if option.Long != "" {
+			longs = append(longs, option.Long)
+		}
+		if option.Short != 0 {
+			shorts = append(shorts, option.Short)
+		}
+	}
+
+	var suggestions []string
+	if isLongOption(arg) || len([]rune(arg)) > 2 {
+		name := strings.TrimLeft(arg, "-")
+		for _, s := range suggest(name, longs) {
+			suggestions = append(suggestions, "--"+s)
+		}
+		return suggestions
+	}
+
+	name := strings.TrimPrefix(arg, "-")
+	for _, s := range shorts {
+		if string(s) != name && strings.EqualFold(string(s), name) {
+			suggestions = append(suggestions, "-"+string(s))
+		}
+	}
+	return suggestions
+}
+
+// didYouMean formats suggestions as a hint, like `did you mean 'push'?`.
+// It returns a blank string if there are no suggestions.
+func didYouMean(suggestions []string) string {
+	n := len(suggestions)
+	if n == 0 {
+		return ""
+	}
+	if n == 1 {
+		return fmt.Sprintf("did you mean '%s'?", suggestions[0])
+	}
+	return fmt.Sprintf(
+		"did you mean %s or '%s'?",
+		quoteArgs(suggestions[:n-1]),
+		suggestions[n-1],
+	)
+}
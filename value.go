@@ -0,0 +1,353 @@
+package charli
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InvalidValueError indicates that an option or positional argument's value
+// couldn't be converted by one of the typed accessors
+// (like [OptionResult.Int] or [Result.ArgInt]).
+type InvalidValueError struct {
+	Option    *Option // the [Option] in question, or nil for positional arguments
+	JoinedArg string  // the argument(s) in question, which may be concatenated
+	Metavar   string  // the metavar, if this is a positional argument
+	Value     string  // the invalid value
+	Type      string  // a description of the expected value, like `an integer`
+	Err       error   // the underlying error
+}
+
+func (err InvalidValueError) Error() string {
+	if err.Metavar != "" {
+		return fmt.Sprintf(
+			"invalid %s '%s': must be %s",
+			err.Metavar,
+			err.JoinedArg,
+			err.Type,
+		)
+	}
+	return fmt.Sprintf("invalid '%s': must be %s", err.JoinedArg, err.Type)
+}
+
+func (err InvalidValueError) Unwrap() error {
+	return err.Err
+}
+
+// Int converts the option's value to an int.
+//
+// If the value can't be converted, an [InvalidValueError] is reported to r
+// (as with [Result.Error]) and 0 is returned.
+// If the option wasn't supplied and has no [Option.Default],
+// 0 is returned without error.
+//
+// [OptionResult.Value] is left untouched.
+// The other typed accessors behave in the same way.
+func (o *OptionResult) Int(r *Result) int {
+	v, _ := convertOption(r, o, "an integer", strconv.Atoi)
+	return v
+}
+
+// Uint converts the option's value to a uint. See [OptionResult.Int].
+func (o *OptionResult) Uint(r *Result) uint {
+	v, _ := convertOption(r, o, "a non-negative integer", parseUint)
+	return v
+}
+
+// Float converts the option's value to a float64. See [OptionResult.Int].
+func (o *OptionResult) Float(r *Result) float64 {
+	v, _ := convertOption(r, o, "a number", parseFloat)
+	return v
+}
+
+// Bool converts the option's value to a bool, as [strconv.ParseBool] does.
+// See [OptionResult.Int].
+//
+// If the option is an [Option.Flag], this is simply [OptionResult.IsSet].
+func (o *OptionResult) Bool(r *Result) bool {
+	if o.Option.Flag {
+		return o.IsSet
+	}
+	v, _ := convertOption(r, o, "true or false", strconv.ParseBool)
+	return v
+}
+
+// Duration converts the option's value to a [time.Duration],
+// as [time.ParseDuration] does. See [OptionResult.Int].
+func (o *OptionResult) Duration(r *Result) time.Duration {
+	v, _ := convertOption(r, o, "a duration (like 1h30m)", time.ParseDuration)
+	return v
+}
+
+// Time converts the option's value to a [time.Time] using layout,
+// as [time.Parse] does. See [OptionResult.Int].
+func (o *OptionResult) Time(r *Result, layout string) time.Time {
+	v, _ := convertOption(r, o, "a time like "+layout, parseTime(layout))
+	return v
+}
+
+// URL converts the option's value to an absolute URL (one with a scheme).
+// See [OptionResult.Int].
+func (o *OptionResult) URL(r *Result) *url.URL {
+	v, _ := convertOption(r, o, "an absolute URL", parseURL)
+	return v
+}
+
+// Path cleans the option's value as a file path, using [filepath.Clean].
+// The path must be non-empty, but needn't exist. See [OptionResult.Int].
+func (o *OptionResult) Path(r *Result) string {
+	v, _ := convertOption(r, o, "a file path", parsePath)
+	return v
+}
+
+// ByteSize converts the option's value to a number of bytes,
+// like `512`, `10KB` or `1.5GiB`. See [OptionResult.Int].
+//
+// Units are case-insensitive.
+// `KB`, `MB`, `GB`, `TB` and `PB` are powers of 1000,
+// while `KiB`, `MiB` (etc.) and single-letter units like `K` and `M`
+// are powers of 1024.
+func (o *OptionResult) ByteSize(r *Result) uint64 {
+	v, _ := convertOption(r, o, "a size (like 10MB or 1.5GiB)", parseByteSize)
+	return v
+}
+
+// IP converts the option's value to an IPv4 or IPv6 address.
+// See [OptionResult.Int].
+func (o *OptionResult) IP(r *Result) netip.Addr {
+	v, _ := convertOption(r, o, "an IP address", netip.ParseAddr)
+	return v
+}
+
+// ArgInt converts the positional argument at index i in [Result.Args] to an
+// int.
+//
+// If the value can't be converted, an [InvalidValueError] is reported
+// (as with [Result.Error]) and 0 is returned.
+// If there is no argument at i, 0 is returned without error.
+//
+// [Result.Args] is left untouched.
+// The other typed accessors behave in the same way.
+func (r *Result) ArgInt(i int) int {
+	v, _ := convertArg(r, i, "an integer", strconv.Atoi)
+	return v
+}
+
+// ArgUint converts the positional argument at i to a uint.
+// See [Result.ArgInt].
+func (r *Result) ArgUint(i int) uint {
+	v, _ := convertArg(r, i, "a non-negative integer", parseUint)
+	return v
+}
+
+// ArgFloat converts the positional argument at i to a float64.
+// See [Result.ArgInt].
+func (r *Result) ArgFloat(i int) float64 {
+	v, _ := convertArg(r, i, "a number", parseFloat)
+	return v
+}
+
+// ArgBool converts the positional argument at i to a bool.
+// See [Result.ArgInt] and [OptionResult.Bool].
+func (r *Result) ArgBool(i int) bool {
+	v, _ := convertArg(r, i, "true or false", strconv.ParseBool)
+	return v
+}
+
+// ArgDuration converts the positional argument at i to a [time.Duration].
+// See [Result.ArgInt] and [OptionResult.Duration].
+func (r *Result) ArgDuration(i int) time.Duration {
+	v, _ := convertArg(r, i, "a duration (like 1h30m)", time.ParseDuration)
+	return v
+}
+
+// ArgTime converts the positional argument at i to a [time.Time] using
+// layout. See [Result.ArgInt] and [OptionResult.Time].
+func (r *Result) ArgTime(i int, layout string) time.Time {
+	v, _ := convertArg(r, i, "a time like "+layout, parseTime(layout))
+	return v
+}
+
+// ArgURL converts the positional argument at i to an absolute URL.
+// See [Result.ArgInt] and [OptionResult.URL].
+func (r *Result) ArgURL(i int) *url.URL {
+	v, _ := convertArg(r, i, "an absolute URL", parseURL)
+	return v
+}
+
+// ArgPath cleans the positional argument at i as a file path.
+// See [Result.ArgInt] and [OptionResult.Path].
+func (r *Result) ArgPath(i int) string {
+	v, _ := convertArg(r, i, "a file path", parsePath)
+	return v
+}
+
+// ArgByteSize converts the positional argument at i to a number of bytes.
+// See [Result.ArgInt] and [OptionResult.ByteSize].
+func (r *Result) ArgByteSize(i int) uint64 {
+	v, _ := convertArg(r, i, "a size (like 10MB or 1.5GiB)", parseByteSize)
+	return v
+}
+
+// ArgIP converts the positional argument at i to an IPv4 or IPv6 address.
+// See [Result.ArgInt].
+func (r *Result) ArgIP(i int) netip.Addr {
+	v, _ := convertArg(r, i, "an IP address", netip.ParseAddr)
+	return v
+}
+
+// convertOption converts the option's value using parse, reporting any
+// error. ok is false if there was no value to convert, or it was invalid.
+func convertOption[T any](
+	r *Result,
+	o *OptionResult,
+	typ string,
+	parse func(string) (T, error),
+) (v T, ok bool) {
+	if !o.IsSet && o.Value == "" {
+		return
+	}
+
+	v, err := parse(o.Value)
+	if err != nil {
+		// Arg is blank if this is the option's default.
+		joinedArg := o.Arg
+		if joinedArg == "" {
+			joinedArg = fmt.Sprintf("%s %s", optionArg(o.Option), o.Value)
+		}
+
+		r.Error(InvalidValueError{
+			Option:    o.Option,
+			JoinedArg: joinedArg,
+			Value:     o.Value,
+			Type:      typ,
+			Err:       err,
+		})
+
+		var zero T
+		return zero, false
+	}
+	return v, true
+}
+
+// convertArg is like convertOption, for the positional argument at i.
+func convertArg[T any](
+	r *Result,
+	i int,
+	typ string,
+	parse func(string) (T, error),
+) (v T, ok bool) {
+	if i < 0 || i >= len(r.Args) {
+		return
+	}
+
+	value := r.Args[i]
+	v, err := parse(value)
+	if err != nil {
+		metavar := "ARG"
+		if r.Command != nil {
+			metavar = r.Command.Args.metavar(i)
+		}
+
+		r.Error(InvalidValueError{
+			JoinedArg: value,
+			Metavar:   metavar,
+			Value:     value,
+			Type:      typ,
+			Err:       err,
+		})
+
+		var zero T
+		return zero, false
+	}
+	return v, true
+}
+
+func parseUint(s string) (uint, error) {
+	v, err := strconv.ParseUint(s, 10, 0)
+	return uint(v), err
+}
+
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func parseTime(layout string) func(string) (time.Time, error) {
+	return func(s string) (time.Time, error) {
+		return time.Parse(layout, s)
+	}
+}
+
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" {
+		return nil, errors.New("missing URL scheme")
+	}
+	return u, nil
+}
+
+func parsePath(s string) (string, error) {
+	if s == "" {
+		return "", errors.New("empty path")
+	}
+	if strings.ContainsRune(s, 0) {
+		return "", errors.New("path contains NUL")
+	}
+	return filepath.Clean(s), nil
+}
+
+var byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1e9,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1e12,
+	"tib": 1 << 40,
+	"p":   1 << 50,
+	"pb":  1e15,
+	"pib": 1 << 50,
+}
+
+func parseByteSize(s string) (uint64, error) {
+	// Split the number from the unit.
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == '.')
+	})
+	if i == -1 {
+		i = len(s)
+	}
+	number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	multiplier, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit '%s'", unit)
+	}
+
+	size := n * multiplier
+	if size >= math.MaxUint64 {
+		return 0, errors.New("size out of range")
+	}
+	return uint64(size), nil
+}
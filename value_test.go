@@ -0,0 +1,199 @@
+package charli_test
+
+import (
+	"errors"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/starriver/charli"
+)
+
+var testValueApp = charli.App{
+	Commands: []charli.Command{
+		{
+			Options: []charli.Option{
+				{Long: "int"},
+				{Short: 'u'},
+				{Long: "float"},
+				{Long: "bool"},
+				{Long: "duration"},
+				{Long: "time"},
+				{Long: "url"},
+				{Long: "path"},
+				{Long: "size"},
+				{Long: "ip"},
+				{Long: "default", Default: "x"},
+				{Long: "unset"},
+				{Short: 'f', Flag: true},
+				{Long: "quiet", Flag: true, Env: "QUIET"},
+				{Long: "off", Flag: true},
+			},
+			Args: charli.Args{
+				Count:    2,
+				Metavars: []string{"PORT", "WAIT"},
+			},
+		},
+	},
+}
+
+func TestValueAccessors(t *testing.T) {
+	r := testValueApp.Parse([]string{
+		"program",
+		"--int=-12",
+		"-u", "12",
+		"--float=1.5",
+		"--bool", "true",
+		"--duration", "1m30s",
+		"--time", "2024-01-02",
+		"--url", "https://example.com/a",
+		"--path", "a//b/../c",
+		"--size", "1.5KiB",
+		"--ip", "::1",
+		"8080", "1h",
+	})
+	if r.Fail {
+		t.Fatalf("unexpected parse failure: %v", r.Errs)
+	}
+
+	o := r.Options
+	if v := o["int"].Int(&r); v != -12 {
+		t.Errorf("Int: got %d", v)
+	}
+	if v := o["u"].Uint(&r); v != 12 {
+		t.Errorf("Uint: got %d", v)
+	}
+	if v := o["float"].Float(&r); v != 1.5 {
+		t.Errorf("Float: got %f", v)
+	}
+	if v := o["bool"].Bool(&r); !v {
+		t.Error("Bool: got false")
+	}
+	if v := o["duration"].Duration(&r); v != 90*time.Second {
+		t.Errorf("Duration: got %v", v)
+	}
+	wantTime := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	if v := o["time"].Time(&r, time.DateOnly); !v.Equal(wantTime) {
+		t.Errorf("Time: got %v", v)
+	}
+	if v := o["url"].URL(&r); v == nil || v.Host != "example.com" {
+		t.Errorf("URL: got %v", v)
+	}
+	if v := o["path"].Path(&r); v != "a/c" {
+		t.Errorf("Path: got %s", v)
+	}
+	if v := o["size"].ByteSize(&r); v != 1536 {
+		t.Errorf("ByteSize: got %d", v)
+	}
+	if v := o["ip"].IP(&r); v != netip.IPv6Loopback() {
+		t.Errorf("IP: got %v", v)
+	}
+	if v := o["unset"].Int(&r); v != 0 {
+		t.Errorf("unset: got %d", v)
+	}
+	if v := r.ArgUint(0); v != 8080 {
+		t.Errorf("ArgUint: got %d", v)
+	}
+	if v := r.ArgDuration(1); v != time.Hour {
+		t.Errorf("ArgDuration: got %v", v)
+	}
+	if v := r.ArgInt(2); v != 0 {
+		t.Errorf("ArgInt (out of range): got %d", v)
+	}
+
+	if r.Fail {
+		t.Errorf("unexpected errors: %v", r.Errs)
+	}
+}
+
+func TestValueAccessorErrors(t *testing.T) {
+	r := testValueApp.Parse([]string{
+		"program",
+		"--int=x",
+		"-u", "1.5",
+		"--url", "example.com",
+		"--size", "10XB",
+		"a", "1h",
+	})
+	if r.Fail {
+		t.Fatalf("unexpected parse failure: %v", r.Errs)
+	}
+
+	o := r.Options
+	if v := o["int"].Int(&r); v != 0 {
+		t.Errorf("Int: got %d", v)
+	}
+	o["u"].Uint(&r)
+	o["url"].URL(&r)
+	o["size"].ByteSize(&r)
+	o["default"].Bool(&r)
+	r.ArgInt(0)
+
+	want := []string{
+		"invalid '--int=x': must be an integer",
+		"invalid '-u 1.5': must be a non-negative integer",
+		"invalid '--url example.com': must be an absolute URL",
+		"invalid '--size 10XB': must be a size (like 10MB or 1.5GiB)",
+		"invalid '--default x': must be true or false",
+		"invalid PORT 'a': must be an integer",
+	}
+	if len(r.Errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(r.Errs), len(want), r.Errs)
+	}
+	for i, err := range r.Errs {
+		if s := err.Error(); s != want[i] {
+			t.Errorf("got '%s', want '%s'", s, want[i])
+		}
+
+		var ive charli.InvalidValueError
+		if !errors.As(err, &ive) {
+			t.Errorf("%d: not an InvalidValueError", i)
+		}
+	}
+
+	var ive charli.InvalidValueError
+	if errors.As(r.Errs[0], &ive) && ive.Option != o["int"].Option {
+		t.Error("option errors should point to the Option")
+	}
+	if errors.As(r.Errs[5], &ive) && ive.Option != nil {
+		t.Error("positional arg errors shouldn't have an Option")
+	}
+
+	if !errors.Is(r.Errs[0], strconv.ErrSyntax) {
+		t.Error("underlying error should be unwrapped")
+	}
+	if o["int"].Value != "x" {
+		t.Error("raw value should be untouched")
+	}
+}
+
+func TestValueFlagBool(t *testing.T) {
+	app := testValueApp
+	app.LookupEnv = func(key string) (string, bool) {
+		if key == "QUIET" {
+			return "1", true
+		}
+		return "", false
+	}
+
+	r := app.Parse([]string{"program", "-f", "a", "1h"})
+	if r.Fail {
+		t.Fatalf("unexpected parse failure: %v", r.Errs)
+	}
+
+	o := r.Options
+	if !o["f"].Bool(&r) {
+		t.Error("supplied flag: got false")
+	}
+	if !o["quiet"].Bool(&r) {
+		t.Error("flag supplied via env: got false")
+	}
+	if o["off"].Bool(&r) {
+		t.Error("unset flag: got true")
+	}
+
+	if r.Fail {
+		t.Errorf("unexpected errors: %v", r.Errs)
+	}
+}
@@ -0,0 +1,163 @@
+package charli
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// minHelpColumn is the narrowest that the right-hand column of an option or
+// command listing can be. If there's less room than this, the column's text
+// starts on the next line instead, with a fixed indent.
+const minHelpColumn = 20
+
+// narrowHelpIndent is the fixed indent used when there's less than
+// minHelpColumn of room.
+const narrowHelpIndent = 6
+
+var ansiRe = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// visibleWidth returns the number of columns s takes up when printed,
+// ignoring ANSI escape sequences.
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(ansiRe.ReplaceAllString(s, ""))
+}
+
+// wrap wraps the words in s to width columns. The text is assumed to start at
+// column col; every line after the first is indented to column indent.
+//
+// Words are only broken at spaces, so a word that's wider than the available
+// room will overflow.
+func wrap(s string, col, indent, width int) string {
+	var b strings.Builder
+	start := true
+	for _, word := range strings.Fields(s) {
+		w := visibleWidth(word)
+		switch {
+		case start:
+			start = false
+		case col+1+w > width:
+			b.WriteString("\n")
+			b.WriteString(strings.Repeat(" ", indent))
+			col = indent
+		default:
+			b.WriteString(" ")
+			col++
+		}
+		b.WriteString(word)
+		col += w
+	}
+	return b.String()
+}
+
+// wrapColumn wraps s for the right-hand column of a listing, which starts at
+// column col, after pad spaces of padding. If the column would be too narrow,
+// s starts on the next line instead.
+func wrapColumn(s string, pad, col, width int) string {
+	if width-col >= minHelpColumn {
+		return strings.Repeat(" ", pad) + wrap(s, col, col, width)
+	}
+	indent := strings.Repeat(" ", narrowHelpIndent)
+	return "\n" + indent +
+		wrap(s, narrowHelpIndent, narrowHelpIndent, width)
+}
+
+// A textBlock is a block of text in a description.
+type textBlock struct {
+	kind textBlockKind
+
+	// text is the block's text. Paragraphs and list items are joined into
+	// a single line. Code blocks retain their lines (and indentation).
+	text string
+}
+
+type textBlockKind uint8
+
+const (
+	paragraphBlock textBlockKind = iota
+	listItemBlock                // starts with `- ` or `* `
+	codeBlock                    // indented lines
+	blankBlock                   // a blank line, separating other blocks
+)
+
+// trimDescription removes a single leading and trailing newline `\n` from
+// text (like [App.Description]), if present.
+func trimDescription(text string) string {
+	text = strings.TrimPrefix(text, "\n")
+	return strings.TrimSuffix(text, "\n")
+}
+
+// textBlocks splits text (like [App.Description]) into blocks.
+//
+// Consecutive lines are joined into paragraphs, which are separated by blank
+// lines. Lines that are already indented are grouped into code blocks.
+// List items (lines starting with `- ` or `* `) start a new block.
+func textBlocks(text string) []textBlock {
+	var blocks []textBlock
+	var lines []string
+	kind := paragraphBlock
+	flush := func() {
+		if len(lines) == 0 {
+			return
+		}
+		sep := " "
+		if kind == codeBlock {
+			sep = "\n"
+		}
+		blocks = append(blocks, textBlock{kind, strings.Join(lines, sep)})
+		lines = nil
+	}
+	add := func(k textBlockKind, line string) {
+		if k != kind || k == listItemBlock {
+			flush()
+			kind = k
+		}
+		lines = append(lines, line)
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+			blocks = append(blocks, textBlock{kind: blankBlock})
+		case line[0] == ' ' || line[0] == '\t':
+			add(codeBlock, line)
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			add(listItemBlock, line)
+		case kind == listItemBlock && len(lines) != 0:
+			// Continue the list item.
+			lines = append(lines, line)
+		default:
+			add(paragraphBlock, line)
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// reflow wraps text (like [App.Description]) to width columns,
+// indenting every line by indent spaces. See [textBlocks].
+//
+// List items wrap with a hanging indent.
+func reflow(text string, indent, width int) string {
+	prefix := strings.Repeat(" ", indent)
+
+	var lines []string
+	for _, block := range textBlocks(text) {
+		switch block.kind {
+		case paragraphBlock:
+			lines = append(lines, prefix+wrap(block.text, indent, indent, width))
+		case listItemBlock:
+			lines = append(lines, prefix+wrap(block.text, indent, indent+2, width))
+		case codeBlock:
+			for _, line := range strings.Split(block.text, "\n") {
+				lines = append(lines, prefix+line)
+			}
+		case blankBlock:
+			lines = append(lines, "")
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}